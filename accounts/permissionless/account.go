@@ -1,6 +1,10 @@
 package permissionless
 
-import "github.com/consensys/gnark/frontend"
+import (
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
 
 type Account struct {
 	X frontend.Variable `gnark:",public"`
@@ -13,3 +17,14 @@ func (me *Account) Define(api frontend.API) error {
 	_, err := api.(frontend.Committer).Commit(me.X)
 	return err
 }
+
+func init() {
+	eonark.RegisterCircuit("permissionless", eonark.Circuit{
+		New: func() frontend.Circuit {
+			return &Account{}
+		},
+		Metadata: map[string]string{
+			"description": "account whose four public inputs X, Y, Z and W are free",
+		},
+	})
+}
@@ -0,0 +1,157 @@
+package eonark
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	csbls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	fcs "github.com/consensys/gnark/frontend/cs"
+)
+
+// ConstraintError is an assignment not satisfying the gate Index:
+// QL⋅L + QR⋅R + QM⋅L⋅R + QO⋅O + QK != 0. Solved tells which of the wire
+// values L, R and O are known, a gate that cannot be solved for its last
+// wire has one unknown. Location is the file:line in the circuit that added
+// the gate, only known when it was compiled with gnark's debug build tag.
+type ConstraintError struct {
+	Index              int
+	QL, QR, QM, QO, QK fr.Element
+	L, R, O            fr.Element
+	Solved             [3]bool
+	Location           string
+	Err                error
+}
+
+func (me *ConstraintError) Error() string {
+	ret := fmt.Sprintf("constraint #%d is not satisfied: qL=%s qR=%s qM=%s qO=%s qK=%s", me.Index, me.QL.String(), me.QR.String(), me.QM.String(), me.QO.String(), me.QK.String())
+	for i, v := range []*fr.Element{&me.L, &me.R, &me.O} {
+		name := [3]string{"l", "r", "o"}[i]
+		if me.Solved[i] {
+			ret += fmt.Sprintf(" %s=%s", name, v.String())
+		} else {
+			ret += fmt.Sprintf(" %s=<unsolved>", name)
+		}
+	}
+	if me.Location != "" {
+		ret += " at " + me.Location
+	}
+	return ret
+}
+
+func (me *ConstraintError) Unwrap() error {
+	return me.Err
+}
+
+// CheckAssignment solves the constraint system for assignment without
+// committing to anything, it returns a *ConstraintError for the first gate
+// found unsatisfied.
+func (me *Pk) CheckAssignment(assignment frontend.Circuit) error {
+	witness, err := frontend.NewWitness(assignment, FIELD)
+	if err != nil {
+		return err
+	}
+	return me.checkWitness(witness)
+}
+
+func (me *Pk) checkWitness(witness witness.Witness) error {
+	cfg, err := backend.NewProverConfig(OPT_PROVER)
+	if err != nil {
+		return err
+	}
+	// the gates are wrapped on a copy, me.ccs may be solved concurrently
+	ccs := me.ccs
+	ccs.Blueprints = slices.Clone(me.ccs.Blueprints)
+	for i, b := range ccs.Blueprints {
+		if _, ok := b.(constraint.BlueprintStateful[constraint.U64]); ok {
+			continue
+		}
+		if g, ok := b.(gateBlueprint); ok {
+			ccs.Blueprints[i] = checkedBlueprint{g}
+		}
+	}
+	opts := append(cfg.SolverOpts, solver.OverrideHint(solver.GetHintID(fcs.Bsb22CommitmentComputePlaceholder), checkBsb22Hint))
+	_, err = ccs.Solve(witness, opts...)
+	var unsatisfied *csbls12381.UnsatisfiedConstraintError
+	if !errors.As(err, &unsatisfied) {
+		return err
+	}
+	ret := &ConstraintError{Index: unsatisfied.CID, Err: unsatisfied.Err}
+	var gate *ConstraintError
+	if errors.As(unsatisfied.Err, &gate) {
+		ret = gate
+		ret.Index = unsatisfied.CID
+	}
+	if id, ok := me.ccs.MDebug[unsatisfied.CID]; ok {
+		// the innermost frame outside of gnark is the call in the circuit
+		for _, l := range me.ccs.DebugInfo[id].Stack {
+			location := me.ccs.SymbolTable.Locations[l]
+			file := me.ccs.SymbolTable.Functions[location.FunctionID].Filename
+			if ret.Location == "" || !strings.Contains(file, "github.com/consensys/gnark") {
+				ret.Location = fmt.Sprintf("%s:%d", file, location.Line)
+			}
+			if !strings.Contains(file, "github.com/consensys/gnark") {
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// checkBsb22Hint stands in for the commitment of the prover, any value the
+// assignment cannot choose will do to check it.
+func checkBsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
+	vals := make([]fr.Element, len(ins))
+	for i := range ins {
+		vals[i].SetBigInt(ins[i])
+	}
+	res := HashCompress(PREFIX_BSB, HashSum(vals...))
+	res.BigInt(outs[0])
+	return nil
+}
+
+type gateBlueprint interface {
+	constraint.BlueprintSolvable[constraint.U64]
+	constraint.BlueprintSparseR1C
+}
+
+// checkedBlueprint records the coefficients and wire values of the gate
+// when solving it fails.
+type checkedBlueprint struct {
+	gateBlueprint
+}
+
+func (me checkedBlueprint) Solve(s constraint.Solver[constraint.U64], inst constraint.Instruction) error {
+	err := me.gateBlueprint.Solve(s, inst)
+	if err == nil {
+		return nil
+	}
+	var gate constraint.SparseR1C
+	me.DecompressSparseR1C(&gate, inst)
+	ret := ConstraintError{Err: err}
+	for _, v := range []struct {
+		dst *fr.Element
+		id  uint32
+	}{{&ret.QL, gate.QL}, {&ret.QR, gate.QR}, {&ret.QM, gate.QM}, {&ret.QO, gate.QO}, {&ret.QK, gate.QC}} {
+		u := s.GetCoeff(v.id)
+		copy(v.dst[:], u[:])
+	}
+	for i, v := range []struct {
+		dst *fr.Element
+		id  uint32
+	}{{&ret.L, gate.XA}, {&ret.R, gate.XB}, {&ret.O, gate.XC}} {
+		if ret.Solved[i] = s.IsSolved(v.id); ret.Solved[i] {
+			u := s.GetValue(constraint.CoeffIdOne, v.id)
+			copy(v.dst[:], u[:])
+		}
+	}
+	return &ret
+}
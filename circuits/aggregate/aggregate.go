@@ -0,0 +1,239 @@
+package aggregate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/commitments/kzg"
+	"github.com/consensys/gnark/std/math/emulated"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/circuits/recursion"
+)
+
+// Item is one native proof to aggregate, as returned by eonark.Pk.Prove.
+type Item struct {
+	Vk      *eonark.Vk
+	Proof   *eonark.Proof
+	Publics []fr.Element
+}
+
+// Aggregator aggregates up to Size proofs made with any of Keys into one proof.
+// Fewer items are padded with copies of the last, left out of the public
+// inputs, see Circuit. The
+// outer circuit only depends on Size and Keys, so its proving key is compiled
+// once and then reused through an eonark.KeyStore.
+type Aggregator struct {
+	Size int
+	Keys []eonark.Vk
+
+	pk *eonark.Pk
+}
+
+// New returns an aggregator for size proofs. The keys must have the same numbers
+// of public inputs and of commitments, they are deduplicated and sorted by address so that the
+// same set always yields the same circuit.
+func New(size int, keys ...*eonark.Vk) (*Aggregator, error) {
+	if size <= 0 {
+		return nil, errors.New("aggregate size must be positive")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no verifying keys given")
+	}
+	ret := Aggregator{Size: size}
+	for _, vk := range keys {
+		if vk.NP != keys[0].NP {
+			return nil, errors.New("verifying keys have different numbers of public inputs")
+		}
+		if len(vk.QC) != len(keys[0].QC) {
+			return nil, errors.New("verifying keys have different numbers of commitments")
+		}
+		address := vk.Address()
+		if !slices.ContainsFunc(ret.Keys, func(v eonark.Vk) bool { return v.Address() == address }) {
+			ret.Keys = append(ret.Keys, *vk)
+		}
+	}
+	slices.SortFunc(ret.Keys, func(a, b eonark.Vk) int {
+		x, y := a.Address(), b.Address()
+		return x.Cmp(&y)
+	})
+	return &ret, nil
+}
+
+func (me *Aggregator) Circuit() (*Circuit, error) {
+	bvk, err := recursion.ValueOfBaseVerifyingKey[FR, G1, G2](me.Keys[0].ToGnarkVerifyingKey())
+	if err != nil {
+		return nil, err
+	}
+	ret := Circuit{
+		Proofs:    make([]recursion.Proof[FR, G1, G2], me.Size),
+		Witnesses: make([]recursion.Witness[FR], me.Size),
+		Switches:  make([]frontend.Variable, me.Size),
+		Used:      make([]frontend.Variable, me.Size),
+		BaseKey:   bvk,
+	}
+	for i := range me.Keys {
+		cvk, err := recursion.ValueOfCircuitVerifyingKey[FR, G1](me.Keys[i].ToGnarkVerifyingKey())
+		if err != nil {
+			return nil, err
+		}
+		address := me.Keys[i].Address()
+		ret.CircuitKeys = append(ret.CircuitKeys, cvk)
+		ret.KeyAddresses = append(ret.KeyAddresses, address.String())
+	}
+	for i := range ret.Proofs {
+		ret.Proofs[i] = recursion.Proof[FR, G1, G2]{
+			BatchedProof: kzg.BatchOpeningProof[FR, G1]{
+				ClaimedValues: make([]emulated.Element[FR], 6+len(me.Keys[0].QC)),
+			},
+			Bsb22Commitments: make([]kzg.Commitment[G1], len(me.Keys[0].QC)),
+		}
+		ret.Witnesses[i].Public = make([]emulated.Element[FR], me.Keys[0].NP)
+	}
+	return &ret, nil
+}
+
+// Assign returns the full outer assignment for items, in order, padded to
+// Size.
+func (me *Aggregator) Assign(items []Item) (*Circuit, error) {
+	ret, err := me.Circuit()
+	if err != nil {
+		return nil, err
+	}
+	publics, err := me.PublicInputs(items)
+	if err != nil {
+		return nil, err
+	}
+	ret.Addresses, ret.Publics, ret.Count, ret.Keys = publics[0], publics[1], publics[2], publics[3]
+	items = slices.Clip(items)
+	for i := range ret.Used {
+		ret.Used[i] = 1
+		if i >= len(items) {
+			ret.Used[i] = 0
+			items = append(items, items[i-1])
+		}
+	}
+	for i, item := range items {
+		ret.Switches[i] = me.index(item.Vk)
+		if ret.Proofs[i], err = recursion.ValueOfProof[FR, G1, G2](item.Proof.ToGnarkPRoof()); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		for j := range item.Publics {
+			ret.Witnesses[i].Public[j] = emulated.ValueOf[FR](item.Publics[j].BigInt(new(big.Int)))
+		}
+	}
+	return ret, nil
+}
+
+// PublicInputs returns the public inputs of the aggregate proof for items.
+func (me *Aggregator) PublicInputs(items []Item) ([]fr.Element, error) {
+	if len(items) == 0 || len(items) > me.Size {
+		return nil, fmt.Errorf("number of items is %d not 1 to %d", len(items), me.Size)
+	}
+	ret := make([]fr.Element, eonark.NUM_PUBLIC)
+	var addresses, publics, keys []fr.Element
+	for i, item := range items {
+		if me.index(item.Vk) < 0 {
+			return nil, fmt.Errorf("item %d: verifying key is not supported", i)
+		}
+		if len(item.Publics) != int(item.Vk.NP) {
+			return nil, fmt.Errorf("item %d: number of public inputs is %d not %d", i, len(item.Publics), item.Vk.NP)
+		}
+		addresses = append(addresses, item.Vk.Address())
+		publics = append(publics, item.Publics...)
+	}
+	for i := range me.Keys {
+		keys = append(keys, me.Keys[i].Address())
+	}
+	ret[0] = eonark.HashSum(addresses...)
+	ret[1] = eonark.HashSum(publics...)
+	ret[2].SetUint64(uint64(len(items)))
+	ret[3] = eonark.HashSum(keys...)
+	return ret, nil
+}
+
+// Compile loads the outer proving key from store or compiles and saves it.
+func (me *Aggregator) Compile(store *eonark.KeyStore) error {
+	circuit, err := me.Circuit()
+	if err != nil {
+		return err
+	}
+	pk, err := store.LoadOrCompile(me.name(), circuit)
+	if err != nil {
+		return err
+	}
+	me.pk = pk
+	return nil
+}
+
+// name keys the outer proving key in the key store by Size and Keys.
+func (me *Aggregator) name() string {
+	var keys []fr.Element
+	for i := range me.Keys {
+		keys = append(keys, me.Keys[i].Address())
+	}
+	hash := eonark.HashSum(keys...)
+	return fmt.Sprintf("aggregate-%d-%s", me.Size, hash.Text(16))
+}
+
+func (me *Aggregator) Vk() (eonark.Vk, error) {
+	if me.pk == nil {
+		return eonark.Vk{}, errors.New("aggregator is not compiled")
+	}
+	return me.pk.Vk(), nil
+}
+
+// Prove verifies the items natively and proves them in one outer proof. The
+// proof is abandoned once ctx is done, see eonark.Pk.ProveContext.
+func (me *Aggregator) Prove(ctx context.Context, items []Item) ([]fr.Element, *eonark.Proof, error) {
+	if me.pk == nil {
+		return nil, nil, errors.New("aggregator is not compiled")
+	}
+	vks := make([]*eonark.Vk, len(items))
+	proofs := make([]*eonark.Proof, len(items))
+	publics := make([][]fr.Element, len(items))
+	for i, item := range items {
+		vks[i], proofs[i], publics[i] = item.Vk, item.Proof, item.Publics
+	}
+	if failed, err := eonark.VerifyBatch(vks, proofs, publics); err != nil {
+		return nil, nil, fmt.Errorf("items %v: %w", failed, err)
+	}
+	assignment, err := me.Assign(items)
+	if err != nil {
+		return nil, nil, err
+	}
+	ret, _, proof, err := me.pk.ProveContext(ctx, assignment)
+	return ret, proof, err
+}
+
+// Aggregate proves items with the default key store and returns the aggregate
+// public inputs, proof and verifying key.
+func Aggregate(ctx context.Context, items []Item) ([]fr.Element, *eonark.Proof, *eonark.Vk, error) {
+	vks := make([]*eonark.Vk, len(items))
+	for i := range items {
+		vks[i] = items[i].Vk
+	}
+	agg, err := New(len(items), vks...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := agg.Compile(&eonark.KEY_STORE); err != nil {
+		return nil, nil, nil, err
+	}
+	publics, proof, err := agg.Prove(ctx, items)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	vk := agg.pk.Vk()
+	return publics, proof, &vk, nil
+}
+
+func (me *Aggregator) index(vk *eonark.Vk) int {
+	address := vk.Address()
+	return slices.IndexFunc(me.Keys, func(v eonark.Vk) bool { return v.Address() == address })
+}
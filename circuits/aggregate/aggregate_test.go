@@ -0,0 +1,331 @@
+package aggregate
+
+import (
+	"context"
+	"math/bits"
+	"os"
+	"path"
+	"sync/atomic"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/accounts/permissionless"
+	"github.com/eon-protocol/eonark/srs"
+)
+
+type squareCircuit struct {
+	X frontend.Variable `gnark:",public"`
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+	W frontend.Variable `gnark:",public"`
+}
+
+func (me *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(me.X, me.X), me.Y)
+	api.AssertIsEqual(api.Mul(me.Y, me.Y), me.Z)
+	_, err := api.(frontend.Committer).Commit(me.W)
+	return err
+}
+
+// testSRS returns the test SRS for circuits of up to 2^logsize constraints.
+func testSRS(t *testing.T, logsize int) *srs.SRS {
+	s, err := srs.ForTests(logsize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// squareItems proves the squares of xs with a key of squareCircuit.
+func squareItems(t *testing.T, xs ...int) []Item {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&squareCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	var items []Item
+	for _, x := range xs {
+		publics, _, proof, err := pk.Prove(&squareCircuit{X: x, Y: x * x, Z: x * x * x * x, W: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, Item{Vk: &vk, Proof: proof, Publics: publics})
+	}
+	return items
+}
+
+// compileTestSRS compiles the outer key of agg on a test SRS large enough
+// for its circuit, the key store only keeps keys of the real SRS.
+func compileTestSRS(t *testing.T, agg *Aggregator) {
+	circuit, err := agg.Circuit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(eonark.FIELD, scs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, sl := plonk.SRSSize(ccs)
+	s := testSRS(t, bits.TrailingZeros(uint(sc-3)))
+	ck, lk, err := s.ProvingKey(sc, sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipk, _, err := plonk.Setup(ccs, &kzg.SRS{Pk: ck, Vk: s.Vk}, &kzg.SRS{Pk: lk, Vk: s.Vk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pk eonark.Pk
+	pk.SetTestSRS(s)
+	if err := pk.FromGnarkConstraintSystemAndProvingKey(ccs, ipk); err != nil {
+		t.Fatal(err)
+	}
+	agg.pk = &pk
+}
+
+func TestAggregate(t *testing.T) {
+	assert := test.NewAssert(t)
+	s := testSRS(t, 10)
+
+	var pka, pkb eonark.Pk
+	pka.SetTestSRS(s)
+	pkb.SetTestSRS(s)
+	assert.NoError(pka.Compile(&permissionless.Account{}))
+	assert.NoError(pkb.Compile(&squareCircuit{}))
+	vka, vkb := pka.Vk(), pkb.Vk()
+
+	var items []Item
+	for _, v := range []struct {
+		pk         *eonark.Pk
+		vk         *eonark.Vk
+		assignment frontend.Circuit
+	}{
+		{&pka, &vka, &permissionless.Account{X: 1, Y: 2, Z: 3, W: 4}},
+		{&pkb, &vkb, &squareCircuit{X: 3, Y: 9, Z: 81, W: 5}},
+		{&pka, &vka, &permissionless.Account{X: 5, Y: 6, Z: 7, W: 8}},
+	} {
+		publics, _, proof, err := v.pk.Prove(v.assignment)
+		assert.NoError(err)
+		items = append(items, Item{Vk: v.vk, Proof: proof, Publics: publics})
+	}
+
+	agg, err := New(len(items), &vka, &vkb, &vka)
+	assert.NoError(err)
+	assert.Equal(2, len(agg.Keys))
+	circuit, err := agg.Circuit()
+	assert.NoError(err)
+	assignment, err := agg.Assign(items)
+	assert.NoError(err)
+	assert.NoError(test.IsSolved(circuit, assignment, eonark.FIELD))
+
+	// the public inputs must commit to the keys and publics of every item
+	wrong, err := agg.Assign(items)
+	assert.NoError(err)
+	wrong.Publics = 0
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+
+	// a proof must not verify under a different key
+	wrong, err = agg.Assign(items)
+	assert.NoError(err)
+	wrong.Switches[1] = 1 - wrong.Switches[1].(int)
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+
+	// every proof is checked against its own transcript
+	wrong, err = agg.Assign([]Item{items[0], items[1], {Vk: items[2].Vk, Proof: items[0].Proof, Publics: items[2].Publics}})
+	assert.NoError(err)
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+}
+
+func TestAggregateSameKey(t *testing.T) {
+	assert := test.NewAssert(t)
+	items := squareItems(t, 2, 3)
+	agg, err := New(len(items), items[0].Vk, items[1].Vk)
+	assert.NoError(err)
+	assert.Equal(1, len(agg.Keys))
+	circuit, err := agg.Circuit()
+	assert.NoError(err)
+	assignment, err := agg.Assign(items)
+	assert.NoError(err)
+	assert.NoError(test.IsSolved(circuit, assignment, eonark.FIELD))
+
+	// there is no other key to switch to
+	wrong, err := agg.Assign(items)
+	assert.NoError(err)
+	wrong.Switches[1] = 1
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+
+	wrong, err = agg.Assign([]Item{items[0], {Vk: items[1].Vk, Proof: items[0].Proof, Publics: items[1].Publics}})
+	assert.NoError(err)
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+
+	// a padded aggregate counts its items only, unlike a repeated item
+	padded, err := New(3, items[0].Vk)
+	assert.NoError(err)
+	circuit, err = padded.Circuit()
+	assert.NoError(err)
+	assignment, err = padded.Assign(items)
+	assert.NoError(err)
+	assert.NoError(test.IsSolved(circuit, assignment, eonark.FIELD))
+	assert.Equal(fr.NewElement(2), assignment.Count)
+	publics, err := padded.PublicInputs(items)
+	assert.NoError(err)
+	repeated, err := padded.PublicInputs(append(items, items[1]))
+	assert.NoError(err)
+	assert.NotEqual(publics, repeated)
+	wrong, err = padded.Assign(items)
+	assert.NoError(err)
+	wrong.Used[2] = 1
+	assert.Error(test.IsSolved(circuit, wrong, eonark.FIELD))
+	_, err = padded.PublicInputs(nil)
+	assert.Error(err)
+}
+
+func TestAggregatorProve(t *testing.T) {
+	if testing.Short() {
+		t.Skip("proves an aggregate of 2^24 constraints")
+	}
+	assert := test.NewAssert(t)
+	items := squareItems(t, 2, 3)
+	agg, err := New(len(items), items[0].Vk)
+	assert.NoError(err)
+	_, _, err = agg.Prove(context.Background(), items)
+	assert.Error(err, "proved before Compile")
+
+	compileTestSRS(t, agg)
+	vk, err := agg.Vk()
+	assert.NoError(err)
+	publics, proof, err := agg.Prove(context.Background(), items)
+	assert.NoError(err)
+	expected, err := agg.PublicInputs(items)
+	assert.NoError(err)
+	assert.Equal(expected, publics)
+	assert.NoError(vk.Verify(proof, publics))
+
+	// the outer key is saved once per set of keys
+	again, err := New(len(items), items[1].Vk)
+	assert.NoError(err)
+	assert.Equal(agg.name(), again.name())
+
+	// items that do not verify are refused before proving
+	wrong := []Item{items[0], {Vk: items[1].Vk, Proof: items[1].Proof, Publics: items[0].Publics}}
+	_, _, err = agg.Prove(context.Background(), wrong)
+	assert.Error(err)
+}
+
+// accountLevel stands in for a compiled aggregator: it computes the node
+// public inputs like Aggregator does but proves them with the account circuit.
+type accountLevel struct {
+	agg    *Aggregator
+	pk     *eonark.Pk
+	proved atomic.Int32
+}
+
+func (me *accountLevel) Vk() (eonark.Vk, error) {
+	return me.pk.Vk(), nil
+}
+
+func (me *accountLevel) PublicInputs(items []Item) ([]fr.Element, error) {
+	return me.agg.PublicInputs(items)
+}
+
+func (me *accountLevel) Prove(ctx context.Context, items []Item) ([]fr.Element, *eonark.Proof, error) {
+	publics, err := me.PublicInputs(items)
+	if err != nil {
+		return publics, nil, err
+	}
+	me.proved.Add(1)
+	_, _, proof, err := me.pk.ProveContext(ctx, &permissionless.Account{X: publics[0], Y: publics[1], Z: publics[2], W: publics[3]})
+	return publics, proof, err
+}
+
+func TestTree(t *testing.T) {
+	assert := test.NewAssert(t)
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	assert.NoError(pk.Compile(&permissionless.Account{}))
+	vk := pk.Vk()
+	agg, err := New(2, &vk)
+	assert.NoError(err)
+
+	var leaves []Item
+	for i := 0; i < 5; i++ {
+		publics, _, proof, err := pk.Prove(&permissionless.Account{X: i, Y: 1, Z: 2, W: 3})
+		assert.NoError(err)
+		leaves = append(leaves, Item{Vk: &vk, Proof: proof, Publics: publics})
+	}
+	level := &accountLevel{agg: agg, pk: &pk}
+	tree := Tree{Arity: 2, Depth: 3, Levels: []Level{level, level, level}, Dir: t.TempDir(), Parallel: 2}
+
+	expected, err := tree.PublicInputs(leaves)
+	assert.NoError(err)
+	publics, proof, err := tree.Prove(context.Background(), leaves)
+	assert.NoError(err)
+	assert.Equal(expected, publics)
+	assert.NoError(vk.Verify(proof, publics))
+	// 3 + 2 + 1 nodes for 5 leaves
+	assert.Equal(int32(6), level.proved.Load())
+	// the padding of the last nodes is not a repeated leaf
+	repeated, err := tree.PublicInputs(append(leaves[:5:5], leaves[4]))
+	assert.NoError(err)
+	assert.NotEqual(publics, repeated)
+
+	// a finished run is resumed entirely from Dir
+	level.proved.Store(0)
+	again, _, err := tree.Prove(context.Background(), leaves)
+	assert.NoError(err)
+	assert.Equal(publics, again)
+	assert.Equal(int32(0), level.proved.Load())
+
+	// a missing node is proved again, the others are reused
+	digest := eonark.HashSum(publics...)
+	assert.NoError(os.Remove(path.Join(tree.Dir, "3."+digest.Text(16)+".PROOF")))
+	_, _, err = tree.Prove(context.Background(), leaves)
+	assert.NoError(err)
+	assert.Equal(int32(1), level.proved.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tree.Dir = ""
+	_, _, err = tree.Prove(ctx, leaves)
+	assert.ErrorIs(err, context.Canceled)
+
+	_, _, err = tree.Prove(context.Background(), append(leaves, leaves...))
+	assert.Error(err)
+}
+
+func TestTreeProve(t *testing.T) {
+	if testing.Short() {
+		t.Skip("proves three aggregates of 2^24 constraints")
+	}
+	assert := test.NewAssert(t)
+	leaves := squareItems(t, 2, 3, 4)
+	// NewTree on the test SRS
+	tree := Tree{Arity: 2, Depth: 2, Dir: t.TempDir(), Parallel: 1}
+	keys := []*eonark.Vk{leaves[0].Vk}
+	for i := 0; i < tree.Depth; i++ {
+		agg, err := New(tree.Arity, keys...)
+		assert.NoError(err)
+		compileTestSRS(t, agg)
+		vk, err := agg.Vk()
+		assert.NoError(err)
+		keys = []*eonark.Vk{&vk}
+		tree.Levels = append(tree.Levels, agg)
+	}
+
+	expected, err := tree.PublicInputs(leaves)
+	assert.NoError(err)
+	publics, proof, err := tree.Prove(context.Background(), leaves)
+	assert.NoError(err)
+	assert.Equal(expected, publics)
+	vk, err := tree.Vk()
+	assert.NoError(err)
+	assert.NoError(vk.Verify(proof, publics))
+}
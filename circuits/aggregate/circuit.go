@@ -0,0 +1,104 @@
+// Package aggregate proves several eonark proofs, possibly of different inner
+// circuits, with one outer proof built on recursion.Verifier.AssertDifferentProofs,
+// or AssertSameProofs when all of them share one key.
+package aggregate
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/selector"
+
+	"github.com/eon-protocol/eonark/circuits/hasher"
+	"github.com/eon-protocol/eonark/circuits/recursion"
+)
+
+type (
+	FR = sw_bls12381.ScalarField
+	G1 = sw_bls12381.G1Affine
+	G2 = sw_bls12381.G2Affine
+	GT = sw_bls12381.GTEl
+)
+
+// Circuit verifies len(Proofs) inner proofs, each against the key selected by
+// its switch, and exposes four public inputs:
+//
+//	Addresses = HashSum(Vk.Address() of every used proof)
+//	Publics   = HashSum(public inputs of every used proof, in order)
+//	Count     = number of used proofs
+//	Keys      = HashSum(Vk.Address() of every supported key)
+//
+// The used proofs come first. The others only pad the circuit to len(Proofs):
+// they are verified but left out of Addresses, Publics and Count.
+type Circuit struct {
+	Proofs    []recursion.Proof[FR, G1, G2]
+	Witnesses []recursion.Witness[FR]
+	Switches  []frontend.Variable
+	Used      []frontend.Variable
+
+	Addresses frontend.Variable `gnark:",public"`
+	Publics   frontend.Variable `gnark:",public"`
+	Count     frontend.Variable `gnark:",public"`
+	Keys      frontend.Variable `gnark:",public"`
+
+	// supported inner keys, compiled into the circuit as constants
+	BaseKey      recursion.BaseVerifyingKey[FR, G1, G2]  `gnark:"-"`
+	CircuitKeys  []recursion.CircuitVerifyingKey[FR, G1] `gnark:"-"`
+	KeyAddresses []frontend.Variable                     `gnark:"-"`
+}
+
+func (me *Circuit) Define(api frontend.API) error {
+	if len(me.Proofs) != len(me.Witnesses) || len(me.Proofs) != len(me.Switches) || len(me.Proofs) != len(me.Used) {
+		return fmt.Errorf("input lengths mismatch")
+	}
+	if len(me.CircuitKeys) != len(me.KeyAddresses) {
+		return fmt.Errorf("key lengths mismatch")
+	}
+	v, err := recursion.NewVerifier[FR, G1, G2, GT](api)
+	if err != nil {
+		return err
+	}
+	opts := []recursion.VerifierOption{recursion.WithCompleteArithmetic(), recursion.WithDerivedFSInputs()}
+	if len(me.CircuitKeys) == 1 {
+		// nothing to select, the Mux below asserts every switch is 0
+		vk := recursion.VerifyingKey[FR, G1, G2]{BaseVerifyingKey: me.BaseKey, CircuitVerifyingKey: me.CircuitKeys[0]}
+		err = v.AssertSameProofs(vk, me.Proofs, me.Witnesses, opts...)
+	} else {
+		err = v.AssertDifferentProofs(me.BaseKey, me.CircuitKeys, me.Switches, me.Proofs, me.Witnesses, opts...)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := emulated.NewField[FR](api)
+	if err != nil {
+		return err
+	}
+	h, err := hasher.NewPoseidon2FromParameters(api)
+	if err != nil {
+		return err
+	}
+	// HashSum folds Compress from 0, a padding proof keeps the accumulators
+	var addresses, publics, count frontend.Variable = 0, 0, 0
+	for i := range me.Proofs {
+		api.AssertIsBoolean(me.Used[i])
+		if i == 0 {
+			api.AssertIsEqual(me.Used[i], 1)
+		} else {
+			api.AssertIsEqual(api.Mul(me.Used[i], api.Sub(1, me.Used[i-1])), 0)
+		}
+		count = api.Add(count, me.Used[i])
+		address := selector.Mux(api, me.Switches[i], me.KeyAddresses...)
+		addresses = api.Select(me.Used[i], h.Compress(addresses, address), addresses)
+		for j := range me.Witnesses[i].Public {
+			public := api.FromBinary(f.ToBits(&me.Witnesses[i].Public[j])...)
+			publics = api.Select(me.Used[i], h.Compress(publics, public), publics)
+		}
+	}
+	api.AssertIsEqual(me.Addresses, addresses)
+	api.AssertIsEqual(me.Publics, publics)
+	api.AssertIsEqual(me.Count, count)
+	api.AssertIsEqual(me.Keys, h.HashSumVars(me.KeyAddresses...))
+	return nil
+}
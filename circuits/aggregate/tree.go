@@ -0,0 +1,238 @@
+package aggregate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/eon-protocol/eonark"
+)
+
+// Level proves one layer of a Tree. *Aggregator is the implementation used by
+// NewTree.
+type Level interface {
+	Vk() (eonark.Vk, error)
+	PublicInputs(items []Item) ([]fr.Element, error)
+	Prove(ctx context.Context, items []Item) ([]fr.Element, *eonark.Proof, error)
+}
+
+// Tree aggregates up to Arity^Depth leaf proofs into a single root proof.
+// Levels[0] aggregates leaves, Levels[i] aggregates the proofs of Levels[i-1].
+// A node with fewer than Arity children is padded by its Level, see Aggregator:
+// its Count is the number of its real children.
+//
+// When Dir is set, every node proof is saved there under its public inputs
+// and reused by later runs, so an interrupted aggregation resumes where it
+// stopped. Up to Parallel nodes are proved at once.
+type Tree struct {
+	Arity    int
+	Depth    int
+	Levels   []Level
+	Dir      string
+	Parallel int
+}
+
+// NewTree compiles (or loads from store) one aggregator per level. The leaf
+// level accepts proofs of any of keys.
+func NewTree(store *eonark.KeyStore, arity, depth int, keys ...*eonark.Vk) (*Tree, error) {
+	if arity < 2 {
+		return nil, errors.New("tree arity must be at least 2")
+	}
+	if depth < 1 {
+		return nil, errors.New("tree depth must be at least 1")
+	}
+	tree := Tree{Arity: arity, Depth: depth, Parallel: 1}
+	for i := 0; i < depth; i++ {
+		agg, err := New(arity, keys...)
+		if err != nil {
+			return nil, err
+		}
+		if err := agg.Compile(store); err != nil {
+			return nil, fmt.Errorf("level %d: %w", i, err)
+		}
+		vk, err := agg.Vk()
+		if err != nil {
+			return nil, err
+		}
+		keys = []*eonark.Vk{&vk}
+		tree.Levels = append(tree.Levels, agg)
+	}
+	return &tree, nil
+}
+
+func (me *Tree) Capacity() int {
+	ret := 1
+	for i := 0; i < me.Depth; i++ {
+		ret *= me.Arity
+	}
+	return ret
+}
+
+func (me *Tree) Vk() (eonark.Vk, error) {
+	return me.Levels[me.Depth-1].Vk()
+}
+
+// PublicInputs returns the public inputs of the root proof for leaves
+// without proving anything.
+func (me *Tree) PublicInputs(leaves []Item) ([]fr.Element, error) {
+	if err := me.check(leaves); err != nil {
+		return nil, err
+	}
+	root, err := me.walk(me.Depth, leaves, func(level int, children []Item) (Item, error) {
+		vk, err := me.Levels[level-1].Vk()
+		if err != nil {
+			return Item{}, err
+		}
+		publics, err := me.Levels[level-1].PublicInputs(children)
+		return Item{Vk: &vk, Publics: publics}, err
+	})
+	return root.Publics, err
+}
+
+// Prove proves every node of the tree, children before parents, and returns
+// the root proof. Independent subtrees are scheduled concurrently.
+func (me *Tree) Prove(ctx context.Context, leaves []Item) ([]fr.Element, *eonark.Proof, error) {
+	if err := me.check(leaves); err != nil {
+		return nil, nil, err
+	}
+	if me.Dir != "" {
+		if err := os.MkdirAll(me.Dir, os.ModePerm); err != nil {
+			return nil, nil, err
+		}
+	}
+	vks := make([]eonark.Vk, me.Depth)
+	for i := range me.Levels {
+		var err error
+		if vks[i], err = me.Levels[i].Vk(); err != nil {
+			return nil, nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, max(me.Parallel, 1))
+	prove := func(level int, children []Item) (Item, error) {
+		vk := &vks[level-1]
+		publics, err := me.Levels[level-1].PublicInputs(children)
+		if err != nil {
+			return Item{}, err
+		}
+		pathproof := me.path(level, publics)
+		if proof, err := me.load(pathproof, vk, publics); err == nil {
+			return Item{Vk: vk, Proof: proof, Publics: publics}, nil
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return Item{}, ctx.Err()
+		}
+		defer func() { <-sem }()
+		if err := ctx.Err(); err != nil {
+			return Item{}, err
+		}
+		publics, proof, err := me.Levels[level-1].Prove(ctx, children)
+		if err != nil {
+			return Item{}, fmt.Errorf("level %d: %w", level-1, err)
+		}
+		if err := me.save(pathproof, proof); err != nil {
+			return Item{}, err
+		}
+		return Item{Vk: vk, Proof: proof, Publics: publics}, nil
+	}
+	root, err := me.walk(me.Depth, leaves, func(level int, children []Item) (Item, error) {
+		item, err := prove(level, children)
+		if err != nil {
+			cancel()
+		}
+		return item, err
+	})
+	return root.Publics, root.Proof, err
+}
+
+func (me *Tree) check(leaves []Item) error {
+	if len(me.Levels) != me.Depth {
+		return fmt.Errorf("tree has %d levels not %d", len(me.Levels), me.Depth)
+	}
+	if len(leaves) == 0 {
+		return errors.New("no leaves to aggregate")
+	}
+	if len(leaves) > me.Capacity() {
+		return fmt.Errorf("number of leaves %d exceeds capacity %d", len(leaves), me.Capacity())
+	}
+	return nil
+}
+
+// walk splits leaves into at most Arity subtrees of the level below, runs them
+// concurrently and hands their roots to node.
+func (me *Tree) walk(level int, leaves []Item, node func(level int, children []Item) (Item, error)) (Item, error) {
+	if level == 0 {
+		return leaves[0], nil
+	}
+	chunk := 1
+	for i := 1; i < level; i++ {
+		chunk *= me.Arity
+	}
+	children := make([]Item, (len(leaves)+chunk-1)/chunk)
+	var group errgroup.Group
+	for i := range children {
+		part := leaves[i*chunk : min((i+1)*chunk, len(leaves))]
+		group.Go(func() (err error) {
+			children[i], err = me.walk(level-1, part, node)
+			return
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return Item{}, err
+	}
+	return node(level, children)
+}
+
+func (me *Tree) path(level int, publics []fr.Element) string {
+	if me.Dir == "" {
+		return ""
+	}
+	digest := eonark.HashSum(publics...)
+	return path.Join(me.Dir, fmt.Sprintf("%d.%s.PROOF", level, digest.Text(16)))
+}
+
+func (me *Tree) load(pathproof string, vk *eonark.Vk, publics []fr.Element) (*eonark.Proof, error) {
+	if pathproof == "" {
+		return nil, os.ErrNotExist
+	}
+	file, err := os.Open(pathproof)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var proof eonark.Proof
+	if _, err := proof.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	if err := vk.Verify(&proof, publics); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (me *Tree) save(pathproof string, proof *eonark.Proof) error {
+	if pathproof == "" {
+		return nil
+	}
+	file, err := os.CreateTemp(me.Dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if _, err := proof.WriteTo(file); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), pathproof)
+}
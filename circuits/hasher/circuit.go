@@ -9,13 +9,19 @@ import (
 
 	poseidonbls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+
+	"github.com/eon-protocol/eonark/zkcore"
 )
 
+var _ zkcore.Hasher[frontend.Variable] = (*Permutation)(nil)
+
 var (
 	ErrInvalidSizebuffer = errors.New("the size of the input should match the size of the hash buffer")
 )
 
-// In-circuit Poseidon2 permutation implementation.
+// In-circuit Poseidon2 permutation implementation, the zkcore.Hasher of
+// circuits.
 type Permutation struct {
 	api    frontend.API
 	params parameters
@@ -33,30 +39,19 @@ type parameters struct {
 
 // ---------------------- constructor (reads from hasher/vars.go) ----------------------
 
-// NewPoseidon2FromParameters builds a Permutation from WIDTH/ROUND_* and SEED
-// defined in vars.go.
+// NewPoseidon2FromParameters builds a Permutation from the zkcore
+// parameters, WIDTH/ROUND_* and SEED in vars.go.
 func NewPoseidon2FromParameters(api frontend.API) (*Permutation, error) {
-	width, rf, rp := WIDTH, ROUND_FULL, ROUND_PARTIAL
-	useSeed := USESEED
-	seed := SEED
-
 	// degreeSBox is obtained from the bls12-381 Poseidon2 parameters.
 	params := parameters{
-		width:           width,
+		width:           WIDTH,
 		degreeSBox:      poseidonbls12381.DegreeSBox(),
-		nbFullRounds:    rf,
-		nbPartialRounds: rp,
-	}
-
-	// Instantiate concrete params (including round keys) with/without seed.
-	var concreteParams *poseidonbls12381.Parameters
-	if useSeed {
-		concreteParams = poseidonbls12381.NewParametersWithSeed(width, rf, rp, seed)
-	} else {
-		concreteParams = poseidonbls12381.NewParameters(width, rf, rp)
+		nbFullRounds:    ROUND_FULL,
+		nbPartialRounds: ROUND_PARTIAL,
 	}
 
 	// Copy round keys into big.Int constants for circuit use.
+	concreteParams := zkcore.Parameters()
 	params.roundKeys = make([][]big.Int, len(concreteParams.RoundKeys))
 	for i := range params.roundKeys {
 		params.roundKeys[i] = make([]big.Int, len(concreteParams.RoundKeys[i]))
@@ -253,8 +248,8 @@ func (h *Permutation) HashCompressVars(x, y frontend.Variable) frontend.Variable
 	return h.Compress(x, y)
 }
 
-// HashSumVars folds values from zero using HashCompressVars.
-func (h *Permutation) HashSumVars(vals ...frontend.Variable) frontend.Variable {
+// Sum folds values from zero using Compress.
+func (h *Permutation) Sum(vals ...frontend.Variable) frontend.Variable {
 	var acc frontend.Variable = 0
 	for i := range vals {
 		acc = h.Compress(acc, vals[i])
@@ -262,9 +257,46 @@ func (h *Permutation) HashSumVars(vals ...frontend.Variable) frontend.Variable {
 	return acc
 }
 
+// HashSumVars is the in-circuit variant of the native HashSum.
+func (h *Permutation) HashSumVars(vals ...frontend.Variable) frontend.Variable {
+	return h.Sum(vals...)
+}
+
 // HashG1Vars matches the native HashG1: compress x=(xq,xm), then y=(yq,ym), then (x,y).
 func (h *Permutation) HashG1Vars(g G1DecomposedVars) frontend.Variable {
 	x := h.Compress(g.XQ, g.XM)
 	y := h.Compress(g.YQ, g.YM)
 	return h.Compress(x, y)
 }
+
+var _ hash.FieldHasher = (*FieldHasher)(nil)
+
+// FieldHasher is the in-circuit HashSum as a gnark hash.FieldHasher, Sum is
+// HashSum of the values written since the last Reset. It matches the hashes
+// given to gnark's native verifier by eonark.OPT_VERIFIER.
+type FieldHasher struct {
+	h   *Permutation
+	acc frontend.Variable
+}
+
+func NewFieldHasher(api frontend.API) (*FieldHasher, error) {
+	h, err := NewPoseidon2FromParameters(api)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldHasher{h: h, acc: 0}, nil
+}
+
+func (f *FieldHasher) Write(data ...frontend.Variable) {
+	for i := range data {
+		f.acc = f.h.Compress(f.acc, data[i])
+	}
+}
+
+func (f *FieldHasher) Sum() frontend.Variable {
+	return f.acc
+}
+
+func (f *FieldHasher) Reset() {
+	f.acc = 0
+}
@@ -10,6 +10,9 @@ import (
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
+
+	"github.com/eon-protocol/eonark/zkcore"
+	"github.com/eon-protocol/eonark/zkcore/testvectors"
 )
 
 // This test suite cross-checks the circuit implementation against the native
@@ -80,3 +83,97 @@ func TestPoseidon2Permutation_MatchesNative(t *testing.T) {
 		log.Println("pass one permutation test iteration")
 	}
 }
+
+// vectorsCircuit recomputes the zkcore test vectors with the gadget.
+type vectorsCircuit struct {
+	Permutation [][4]frontend.Variable // in, out
+	Compress    [][3]frontend.Variable // x, y, out
+	Sum         [][]frontend.Variable  // in..., out
+	HashG1      []G1DecomposedVars
+	HashG1Out   []frontend.Variable
+	Transcript  [][]frontend.Variable // id, previous, bindings..., out
+}
+
+func (c *vectorsCircuit) Define(api frontend.API) error {
+	h, err := NewPoseidon2FromParameters(api)
+	if err != nil {
+		return err
+	}
+	for _, v := range c.Permutation {
+		vars := []frontend.Variable{v[0], v[1]}
+		if err := h.Permutation(vars); err != nil {
+			return err
+		}
+		api.AssertIsEqual(vars[0], v[2])
+		api.AssertIsEqual(vars[1], v[3])
+	}
+	for _, v := range c.Compress {
+		api.AssertIsEqual(h.Compress(v[0], v[1]), v[2])
+	}
+	fh, err := NewFieldHasher(api)
+	if err != nil {
+		return err
+	}
+	for _, v := range c.Sum {
+		api.AssertIsEqual(h.Sum(v[:len(v)-1]...), v[len(v)-1])
+		fh.Reset()
+		fh.Write(v[:len(v)-1]...)
+		api.AssertIsEqual(fh.Sum(), v[len(v)-1])
+	}
+	for i, v := range c.HashG1 {
+		api.AssertIsEqual(h.HashG1Vars(v), c.HashG1Out[i])
+	}
+	for _, v := range c.Transcript {
+		api.AssertIsEqual(h.HashSumVars(v[:len(v)-1]...), v[len(v)-1])
+	}
+	return nil
+}
+
+func TestVectors(t *testing.T) {
+	assert := test.NewAssert(t)
+	vectors, err := testvectors.Load()
+	assert.NoError(err)
+
+	var circuit, assignment vectorsCircuit
+	for _, v := range vectors.Permutation {
+		circuit.Permutation = append(circuit.Permutation, [4]frontend.Variable{})
+		assignment.Permutation = append(assignment.Permutation, [4]frontend.Variable{v.In[0], v.In[1], v.Out[0], v.Out[1]})
+	}
+	for _, v := range vectors.Compress {
+		circuit.Compress = append(circuit.Compress, [3]frontend.Variable{})
+		assignment.Compress = append(assignment.Compress, [3]frontend.Variable{v.X, v.Y, v.Out})
+	}
+	for _, v := range vectors.Sum {
+		var vars []frontend.Variable
+		for _, in := range v.In {
+			vars = append(vars, in)
+		}
+		circuit.Sum = append(circuit.Sum, make([]frontend.Variable, len(v.In)+1))
+		assignment.Sum = append(assignment.Sum, append(vars, v.Out))
+	}
+	for _, v := range vectors.HashG1 {
+		d := DecomposeG1(testvectors.G1(v.X, v.Y))
+		circuit.HashG1 = append(circuit.HashG1, G1DecomposedVars{})
+		assignment.HashG1 = append(assignment.HashG1, G1DecomposedVars{XQ: d[0][0], XM: d[0][1], YQ: d[1][0], YM: d[1][1]})
+		circuit.HashG1Out = append(circuit.HashG1Out, nil)
+		assignment.HashG1Out = append(assignment.HashG1Out, v.Out)
+	}
+	ids := []frbls12381.Element{zkcore.CID_GAMMA, zkcore.CID_BETA, zkcore.CID_ALPHA, zkcore.CID_ZETA}
+	for _, v := range vectors.Transcript {
+		for i := range ids {
+			vars := []frontend.Variable{ids[i]}
+			if i > 0 {
+				vars = append(vars, v.Challenges[i-1])
+			}
+			for _, b := range v.Bindings[i] {
+				vars = append(vars, b)
+			}
+			circuit.Transcript = append(circuit.Transcript, make([]frontend.Variable, len(vars)+1))
+			assignment.Transcript = append(assignment.Transcript, append(vars, v.Challenges[i]))
+		}
+	}
+	assert.NoError(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+
+	assignment.Compress[0][2] = 0
+	assert.Error(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+}
@@ -70,11 +70,11 @@ type FSInputs struct {
 	// VK
 	S                  [3]G1Decomp
 	Ql, Qr, Qm, Qo, Qk G1Decomp
-	Qc                 []G1Decomp
+	Qc                 []G1Decomp // one per commitment, any number
 
 	// Proof
 	W   [3]G1Decomp // CW1,CW2,CW3
-	BSB []G1Decomp  // set as 1 in vk.go
+	BSB []G1Decomp  // one per commitment, in the order of Qc
 	Z   G1Decomp    // CPZ
 	H   [3]G1Decomp // CH1,CH2,CH3
 
@@ -818,6 +818,11 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	if len(proof.Bsb22Commitments) != len(vk.Qcp) {
 		return nil, nil, nil, fmt.Errorf("BSB22 commitment number mismatch")
 	}
+	if cfg.deriveFS {
+		if cfg.fsIn, err = v.DeriveFSInputs(vk, proof, witness); err != nil {
+			return nil, nil, nil, fmt.Errorf("derive fs inputs: %w", err)
+		}
+	}
 
 	var gamma, beta, alpha, zeta *emulated.Element[FR]
 
@@ -847,14 +852,18 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 			return h.HashG1Vars(hasher.G1DecomposedVars{XQ: g.XQ, XM: g.XM, YQ: g.YQ, YM: g.YM})
 		}
 
-		// gamma = CID_GAMMA, S1,S2,S3, Ql, Qr, Qm, Qo, Qk, QC, CW1, CW2, CW3, publics...
+		// gamma = CID_GAMMA, S1,S2,S3, Ql, Qr, Qm, Qo, Qk, QC..., number of publics, CW1, CW2, CW3, publics...
+		if len(cfg.fsIn.Qc) != len(vk.Qcp) || len(cfg.fsIn.BSB) != len(proof.Bsb22Commitments) {
+			return nil, nil, nil, fmt.Errorf("fs inputs have %d QC and %d BSB, expected %d and %d", len(cfg.fsIn.Qc), len(cfg.fsIn.BSB), len(vk.Qcp), len(proof.Bsb22Commitments))
+		}
 		var gIns []frontend.Variable
 		gIns = append(gIns, cfg.fsIn.CIDGamma)
 		gIns = append(gIns, hashG1(cfg.fsIn.S[0]), hashG1(cfg.fsIn.S[1]), hashG1(cfg.fsIn.S[2]))
 		gIns = append(gIns, hashG1(cfg.fsIn.Ql), hashG1(cfg.fsIn.Qr), hashG1(cfg.fsIn.Qm), hashG1(cfg.fsIn.Qo), hashG1(cfg.fsIn.Qk))
-		if len(cfg.fsIn.Qc) == 1 {
-			gIns = append(gIns, hashG1(cfg.fsIn.Qc[0]))
+		for i := range cfg.fsIn.Qc {
+			gIns = append(gIns, hashG1(cfg.fsIn.Qc[i]))
 		}
+		gIns = append(gIns, vk.NbPublicVariables)
 
 		gIns = append(gIns, hashG1(cfg.fsIn.W[0]), hashG1(cfg.fsIn.W[1]), hashG1(cfg.fsIn.W[2]))
 		gIns = append(gIns, cfg.fsIn.Publics...)
@@ -1138,33 +1147,31 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 // the digests/evaluations with powers of γ.
 // It returns the folded opening proof and folded commitment (and γ for debugging/use).
 // Requirements:
-//   - len(vk.Qcp) >= 1 (we use exactly one QC to match the execution snippet)
-//   - proof.BatchedProof.ClaimedValues has at least 7 elements:
-//     [COL, CVL, CVR, CVO, CS1, CS2, CQC]
+//   - proof.BatchedProof.ClaimedValues has 6+len(vk.Qcp) elements:
+//     [COL, CVL, CVR, CVO, CS1, CS2, CQC...]
 func (v *Verifier[FR, G1El, G2El, GtEl]) FoldProofExecStyle(
 	cidGamma frontend.Variable, // CID_GAMMA（constant type)
 	linearizedPolynomialDigest *G1El, // lpd
 	proof Proof[FR, G1El, G2El], // CW1,CW2,CW3,Z(ωζ)
-	vk VerifyingKey[FR, G1El, G2El], // S1,S2, QC
+	vk VerifyingKey[FR, G1El, G2El], // S1,S2, QC...
 	zeta *emulated.Element[FR], // ζ
 ) (kzg.OpeningProof[FR, G1El], kzg.Commitment[G1El], *emulated.Element[FR], error) {
 
 	var retP kzg.OpeningProof[FR, G1El]
 	var retC kzg.Commitment[G1El]
+
+	// --- sanity checks to match the execution layout ---
+	nbClaimed := 6 + len(vk.Qcp)
+	if len(proof.BatchedProof.ClaimedValues) != nbClaimed {
+		return retP, retC, nil, fmt.Errorf("claimed values need %d entries [COL,CVL,CVR,CVO,CS1,CS2,CQC...], got %d", nbClaimed, len(proof.BatchedProof.ClaimedValues))
+	}
+
 	var fr FR
 	toEmu := func(x frontend.Variable) *emulated.Element[FR] {
 		bbits := bits.ToBinary(v.api, x, bits.WithNbDigits(fr.Modulus().BitLen()))
 		return v.scalarApi.FromBits(bbits...)
 	}
 
-	// --- sanity checks to match the execution layout ---
-	if len(vk.Qcp) < 1 {
-		return retP, retC, nil, fmt.Errorf("need at least 1 QC commitment in vk.Qcp")
-	}
-	if len(proof.BatchedProof.ClaimedValues) < 7 {
-		return retP, retC, nil, fmt.Errorf("claimed values need at least 7 entries [COL,CVL,CVR,CVO,CS1,CS2,CQC]")
-	}
-
 	// helper: emulated.Element[FR] -> frontend.Variable
 	toVar := func(e *emulated.Element[FR]) frontend.Variable {
 		bs := v.scalarApi.ToBits(e)
@@ -1177,101 +1184,55 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) FoldProofExecStyle(
 		return retP, retC, nil, fmt.Errorf("poseidon2 params: %w", err)
 	}
 
-	// helper: hash G1 commitment via generic hint
-	hashCommit := func(c kzg.Commitment[G1El]) (frontend.Variable, error) {
-		return v.hashCommitmentByGenericHint(c)
-	}
-
 	// --------- 1) compute γ ---------
-	// HashG1(lpd)
-	hLpd, err := hashCommit(kzg.Commitment[G1El]{G1El: *linearizedPolynomialDigest})
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash lpd via hint: %w", err)
-	}
-	// HashG1(CW1..3)
-	hCW1, err := hashCommit(proof.LRO[0])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash CW1 via hint: %w", err)
-	}
-	hCW2, err := hashCommit(proof.LRO[1])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash CW2 via hint: %w", err)
-	}
-	hCW3, err := hashCommit(proof.LRO[2])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash CW3 via hint: %w", err)
+	// digests: lpd, CW1..3, S1, S2, QC...
+	digests := []*G1El{
+		linearizedPolynomialDigest,
+		&proof.LRO[0].G1El,
+		&proof.LRO[1].G1El,
+		&proof.LRO[2].G1El,
+		&vk.S[0].G1El,
+		&vk.S[1].G1El,
 	}
-	// HashG1(S1,S2)
-	hS1, err := hashCommit(vk.S[0])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash S1 via hint: %w", err)
+	for i := range vk.Qcp {
+		digests = append(digests, &vk.Qcp[i].G1El)
 	}
-	hS2, err := hashCommit(vk.S[1])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash S2 via hint: %w", err)
+
+	// g := HashSum(CID_GAMMA, zeta, HashG1(lpd), HashG1(CW1..3), HashG1(S1), HashG1(S2), HashG1(QC)...,
+	//              COL, CVL, CVR, CVO, CS1, CS2, CQC..., CZO)
+	gIns := []frontend.Variable{cidGamma, toVar(zeta)}
+	for i, d := range digests {
+		hd, err := v.hashCommitmentByGenericHint(kzg.Commitment[G1El]{G1El: *d})
+		if err != nil {
+			return retP, retC, nil, fmt.Errorf("hash digest %d via hint: %w", i, err)
+		}
+		gIns = append(gIns, hd)
 	}
-	// HashG1(QC) —— only use the first QC commitment, aligning with the execution code
-	hQC, err := hashCommit(vk.Qcp[0])
-	if err != nil {
-		return retP, retC, nil, fmt.Errorf("hash QC via hint: %w", err)
+	for i := range proof.BatchedProof.ClaimedValues {
+		gIns = append(gIns, toVar(&proof.BatchedProof.ClaimedValues[i]))
 	}
-
-	// Claimed values, aligned with the execution code
-	COL := toVar(&proof.BatchedProof.ClaimedValues[0])
-	CVL := toVar(&proof.BatchedProof.ClaimedValues[1])
-	CVR := toVar(&proof.BatchedProof.ClaimedValues[2])
-	CVO := toVar(&proof.BatchedProof.ClaimedValues[3])
-	CS1 := toVar(&proof.BatchedProof.ClaimedValues[4])
-	CS2 := toVar(&proof.BatchedProof.ClaimedValues[5])
-	CQC := toVar(&proof.BatchedProof.ClaimedValues[6])
-
 	// CZO = Z(ω·ζ)
-	CZO := toVar(&proof.ZShiftedOpening.ClaimedValue)
-
-	// ζ
-	zetaVar := toVar(zeta)
-
-	// g := HashSum(CID_GAMMA, zeta, HashG1(lpd), HashG1(CW1..3), HashG1(S1), HashG1(S2), HashG1(QC),
-	//              COL, CVL, CVR, CVO, CS1, CS2, CQC, CZO)
-	gammaVar := h.HashSumVars(
-		cidGamma,
-		zetaVar,
-		hLpd,
-		hCW1, hCW2, hCW3,
-		hS1, hS2, hQC,
-		COL, CVL, CVR, CVO, CS1, CS2, CQC,
-		CZO,
-	)
-	// gamma := v.scalarApi.NewElement(gammaVar)
+	gIns = append(gIns, toVar(&proof.ZShiftedOpening.ClaimedValue))
+	gammaVar := h.HashSumVars(gIns...)
 	gamma := toEmu(gammaVar)
 
-	// --------- 2) compute powers of g: [1, g, g², ..., g⁶] ---------
-	const n7 = 7
-	gs := make([]*emulated.Element[FR], n7)
+	// --------- 2) compute powers of g: [1, g, g², ...] ---------
+	gs := make([]*emulated.Element[FR], nbClaimed)
 	gs[0] = v.scalarApi.One()
-	for i := 1; i < n7; i++ {
+	for i := 1; i < nbClaimed; i++ {
 		gs[i] = v.scalarApi.Mul(gs[i-1], gamma)
 	}
 
 	// --------- 3) fold evaluations：foldeval = Σ v_i * gs[i] ---------
-	// orders：COL, CVL, CVR, CVO, CS1, CS2, CQC (aligned with execution code)
+	// orders：COL, CVL, CVR, CVO, CS1, CS2, CQC... (aligned with execution code)
 	foldeval := v.scalarApi.Mul(&proof.BatchedProof.ClaimedValues[0], gs[0])
-	for i := 1; i < n7; i++ {
+	for i := 1; i < nbClaimed; i++ {
 		term := v.scalarApi.Mul(&proof.BatchedProof.ClaimedValues[i], gs[i])
 		foldeval = v.scalarApi.Add(foldeval, term)
 	}
 
 	// --------- 4) fold digests：folddigest = Σ gs[i] * C_i ---------
-	points := []*G1El{
-		linearizedPolynomialDigest,
-		&proof.LRO[0].G1El,
-		&proof.LRO[1].G1El,
-		&proof.LRO[2].G1El,
-		&vk.S[0].G1El,
-		&vk.S[1].G1El,
-		&vk.Qcp[0].G1El,
-	}
-	foldedDigest, err := v.curve.MultiScalarMul(points, gs)
+	foldedDigest, err := v.curve.MultiScalarMul(digests, gs)
 	if err != nil {
 		return retP, retC, nil, fmt.Errorf("multi scalar mul (fold digests): %w", err)
 	}
@@ -1311,7 +1272,7 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) AssertSameProofs(vk VerifyingKey[FR, G1
 		return fmt.Errorf("no proofs to check")
 	}
 	if len(proofs) == 1 {
-		return v.AssertProof(vk, proofs[0], witnesses[0])
+		return v.AssertProof(vk, proofs[0], witnesses[0], opts...)
 	}
 	var foldedDigests []kzg.Commitment[G1El]
 	var foldedProofs []kzg.OpeningProof[FR, G1El]
@@ -1,7 +1,9 @@
 package recursion
 
 import (
+	"flag"
 	"fmt"
+	"math/bits"
 	"os"
 	"testing"
 	"time"
@@ -12,18 +14,33 @@ import (
 	frbls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 
 	// gnark
-	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
-	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
 	"github.com/consensys/gnark/std/hash/mimc"
 	"github.com/consensys/gnark/test"
 
-	"github.com/eon-protocol/eonark/circuits/hasher"
-
 	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/srs"
 )
 
+var share = flag.Bool("share", false, "prove on the SRS of eonark.SRS_SOURCE and export the outer proof, vk and KZG vk to share")
+
+// useTestSRS makes pk compile on a test SRS large enough for ccs, unless
+// -share is set.
+func useTestSRS(t *testing.T, pk *eonark.Pk, ccs constraint.ConstraintSystem) {
+	if *share {
+		return
+	}
+	sc, _ := plonk.SRSSize(ccs)
+	s, err := srs.ForTests(bits.TrailingZeros(uint(sc - 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk.SetTestSRS(s)
+}
+
 //
 // -------------------- Inner circuit layer --------------------
 //
@@ -53,65 +70,22 @@ func (me *innerCircuit) Define(api frontend.API) error {
 	return nil
 }
 
-//
-// -------------------- Outer circuit layer: Recursive verifier (BLS12-381), using Poseidon2-FS --------------------
-//
-
-type outerCircuitBLS struct {
-	// Recursive verifier needs 3 components
-	Proof        Proof[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine]
-	VerifyingKey VerifyingKey[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine] `gnark:"-"`
-
-	// Inner public witness
-	InnerWitness Witness[sw_bls12381.ScalarField]
-	X            frontend.Variable `gnark:",public"`
-	Y            frontend.Variable `gnark:",public"`
-	Z            frontend.Variable `gnark:",public"`
-	W            frontend.Variable `gnark:",public"`
-
-	// Poseidon2-FS composed inputs, injected as options; not public
-	FS FSInputs `gnark:"-"`
-}
-
-func (c *outerCircuitBLS) Define(api frontend.API) error {
-	v, err := NewVerifier[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine, sw_bls12381.GTEl](api)
-	if err != nil {
-		return err
-	}
-	// Key point: pass FSInputs to force the use of Poseidon2-FS branch; CompleteArithmetic is safe
-	return v.AssertProof(
-		c.VerifyingKey,
-		c.Proof,
-		c.InnerWitness,
-		WithCompleteArithmetic(),
-		WithFSInputs(&c.FS),
-	)
-}
-
-//
-// -------------------- Tool: Decompose G1 point for FS --------------------
-//
-
-func decomposeG1ForFS(a bls12381.G1Affine) G1Decomp {
-	d := hasher.DecomposeG1(a) // [2][2]fr.Element
-	return G1Decomp{
-		XQ: d[0][0].String(),
-		XM: d[0][1].String(),
-		YQ: d[1][0].String(),
-		YM: d[1][1].String(),
-	}
-}
-
 //
 // -------------------- Test: BLS12-381 Inner/Outer Same Field + Poseidon2-FS --------------------
 //
 
 func Test_Recursion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the outer circuit has millions of constraints")
+	}
 	assert := test.NewAssert(t)
 
-	// 1) Compile the inner circuit: compile + prove (using SRS in share folder)
+	// 1) Compile the inner circuit: compile + prove
+	innerCS, err := frontend.Compile(ecc.BLS12_381.ScalarField(), scs.NewBuilder, &innerCircuit{})
+	assert.NoError(err)
 	var pk eonark.Pk
-	err := pk.Compile(&innerCircuit{})
+	useTestSRS(t, &pk, innerCS)
+	err = pk.Compile(&innerCircuit{})
 	assert.NoError(err)
 
 	// inner circuit assignment: X=1 (satisfies X*X=1)
@@ -128,112 +102,14 @@ func Test_Recursion(t *testing.T) {
 	vkMine := pk.Vk()
 	assert.NoError(vkMine.Verify(proofMine, publicsMine))
 
-	// 2) Bridge to gnark types: to align with the format required by the outer circuit
-	gnarkVK := vkMine.ToGnarkVerifyingKey()
-	gnarkProof := proofMine.ToGnarkPRoof()
-
-	// 3) Extract public witness vector from gnark witness
-	innerWitAll, err := frontend.NewWitness(innerAssign, ecc.BLS12_381.ScalarField())
-	assert.NoError(err)
-	innerPubWit, err := innerWitAll.Public()
-	assert.NoError(err)
-
-	// type conversion: gnark types to sw_bls12381 types
-	circuitVk, err := ValueOfVerifyingKey[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine](gnarkVK)
-	assert.NoError(err)
-	circuitProof, err := ValueOfProof[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine](gnarkProof)
+	// 2) Outer circuit verifying the inner proof: placeholder + assignment
+	var publics [eonark.NUM_PUBLIC]frbls12381.Element
+	copy(publics[:], publicsMine)
+	outer, err := NewOuterCircuit(vkMine)
 	assert.NoError(err)
-	circuitWitness, err := ValueOfWitness[sw_bls12381.ScalarField](innerPubWit)
+	assign, err := AssignOuterCircuit(vkMine, proofMine, publics)
 	assert.NoError(err)
 
-	// 4) Assemble Poseidon2-FS inputs (fsIn), strictly align with zk/vk.go feeding order
-	//
-	// First, extract gnark's "native bls12-381 struct" for G1 commitments
-	vkBLS := gnarkVK.(*plonkbls12381.VerifyingKey)
-	prBLS := gnarkProof.(*plonkbls12381.Proof)
-
-	var fsIn FSInputs
-
-	// (a) fixed scalars: γ, β, α, ζ
-	// These are the constants used in the Poseidon2-FS circuit, hardcoded for the test
-	// They are not public, but used in the FS transcript
-	fsIn.CIDGamma = "12136437972164249638515815863518169381248623050802518443499856540155713785793"
-	fsIn.CIDBeta = "18573803297957083279407999548582433273399322018814582391185078724486099338357"
-	fsIn.CIDAlpha = "49747578351961873600101888628702675272467029400415710410441263855875020310598"
-	fsIn.CIDZeta = "39057712567180736910604556313519348712189848041390074835666431785905701131882"
-
-	// (b) VK：S, Ql..Qk, Qc
-	fsIn.S[0] = decomposeG1ForFS(vkBLS.S[0])
-	fsIn.S[1] = decomposeG1ForFS(vkBLS.S[1])
-	fsIn.S[2] = decomposeG1ForFS(vkBLS.S[2])
-
-	fsIn.Ql = decomposeG1ForFS(vkBLS.Ql)
-	fsIn.Qr = decomposeG1ForFS(vkBLS.Qr)
-	fsIn.Qm = decomposeG1ForFS(vkBLS.Qm)
-	fsIn.Qo = decomposeG1ForFS(vkBLS.Qo)
-	fsIn.Qk = decomposeG1ForFS(vkBLS.Qk)
-
-	// only 1 QC commitment is used, aligning with the execution code
-	fsIn.Qc = make([]G1Decomp, len(vkBLS.Qcp))
-	for i := range vkBLS.Qcp {
-		fsIn.Qc[i] = decomposeG1ForFS(vkBLS.Qcp[i])
-	}
-
-	// (c) Proof：W=LRO，BSB，Z，H
-	fsIn.W[0] = decomposeG1ForFS(prBLS.LRO[0])
-	fsIn.W[1] = decomposeG1ForFS(prBLS.LRO[1])
-	fsIn.W[2] = decomposeG1ForFS(prBLS.LRO[2])
-
-	fsIn.BSB = make([]G1Decomp, len(prBLS.Bsb22Commitments))
-	for i := range prBLS.Bsb22Commitments {
-		fsIn.BSB[i] = decomposeG1ForFS(prBLS.Bsb22Commitments[i])
-	}
-
-	fsIn.Z = decomposeG1ForFS(prBLS.Z)
-
-	fsIn.H[0] = decomposeG1ForFS(prBLS.H[0])
-	fsIn.H[1] = decomposeG1ForFS(prBLS.H[1])
-	fsIn.H[2] = decomposeG1ForFS(prBLS.H[2])
-
-	{
-		v, ok := innerPubWit.Vector().(frbls12381.Vector)
-		if !ok {
-			t.Fatalf("unexpected public vector type: %T", innerPubWit.Vector())
-		}
-		fsIn.Publics = make([]frontend.Variable, len(v))
-		for i := range v {
-			// stringify each fr.Element to match the expected format
-			// in the Poseidon2-FS circuit
-			fsIn.Publics[i] = v[i].String()
-		}
-	}
-
-	// 5) Outer circuit: placeholder + assignment
-	inner := &innerCircuit{}
-	innerCS, err := frontend.Compile(ecc.BLS12_381.ScalarField(), scs.NewBuilder, inner)
-	if err != nil {
-		panic(err)
-	}
-
-	outer := &outerCircuitBLS{
-		// Proof:        PlaceholderProof[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine](pk.ToGnarkConstraintSystem()),
-		// InnerWitness: PlaceholderWitness[sw_bls12381.ScalarField](pk.ToGnarkConstraintSystem()),
-		Proof:        PlaceholderProof[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine](innerCS),
-		InnerWitness: PlaceholderWitness[sw_bls12381.ScalarField](innerCS),
-		VerifyingKey: circuitVk, // non-public, injected via option
-		FS:           fsIn,      // non-public, injected via option
-	}
-	assign := &outerCircuitBLS{
-		Proof:        circuitProof,
-		InnerWitness: circuitWitness,
-		VerifyingKey: circuitVk,
-		X:            0,
-		Y:            0,
-		Z:            0,
-		W:            0,
-		FS:           fsIn,
-	}
-
 	// just for debugging: test for circuit size segmentation
 	cs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), scs.NewBuilder, outer)
 	if err != nil {
@@ -245,13 +121,14 @@ func Test_Recursion(t *testing.T) {
 		cs.GetNbSecretVariables(),
 	)
 
-	// 6) verify the outer circuit: IsSolved
+	// 3) verify the outer circuit: IsSolved
 	err = test.IsSolved(outer, assign, ecc.BLS12_381.ScalarField())
 	assert.NoError(err)
 	fmt.Printf("outer circuit solved\n")
 
-	// 7) verify circuit using zk package
+	// 4) verify circuit using zk package
 	var pkOuter eonark.Pk
+	useTestSRS(t, &pkOuter, cs)
 	if err := pkOuter.Compile(outer); err != nil {
 		t.Fatalf("outer compile: %v", err)
 	}
@@ -272,6 +149,9 @@ func Test_Recursion(t *testing.T) {
 	fmt.Printf("outer circuit verified\n")
 
 	// ========= export outer circuit's proof / vk / KZG VK =========
+	if !*share {
+		return
+	}
 	if err := os.MkdirAll("share", 0o755); err != nil {
 		t.Fatalf("mkdir share: %v", err)
 	}
@@ -314,3 +194,53 @@ func Test_Recursion(t *testing.T) {
 	fmt.Printf("outer proof/vk/kzgvk exported\n")
 
 }
+
+// TestRecursionSolved is Test_Recursion without proving the outer circuit,
+// which takes minutes: the inner proof is made on the test SRS and the outer
+// assignment is solved.
+func TestRecursionSolved(t *testing.T) {
+	innerCS, err := frontend.Compile(eonark.FIELD, scs.NewBuilder, &innerCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pk eonark.Pk
+	useTestSRS(t, &pk, innerCS)
+	if err := pk.Compile(&innerCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	outer, err := NewOuterCircuit(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one outer circuit for every proof of the key
+	var assigns []*OuterCircuit
+	for _, inner := range []innerCircuit{{X: 1, Y: 2, Z: 3, W: 4}, {X: 1, Y: 5, Z: 6, W: 7}} {
+		publics, _, proof, err := pk.Prove(&inner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+		assign, err := AssignOuterCircuit(vk, proof, [eonark.NUM_PUBLIC]frbls12381.Element(publics))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(outer, assign, eonark.FIELD); err != nil {
+			t.Fatal(err)
+		}
+		assigns = append(assigns, assign)
+	}
+	assign := *assigns[0]
+	assign.Publics[1] = 5
+	if test.IsSolved(outer, &assign, eonark.FIELD) == nil {
+		t.Fatal("outer circuit solved for other public inputs")
+	}
+	// the FS inputs come from the proof, not from the public inputs
+	assign = *assigns[0]
+	assign.InnerWitness, assign.Publics = assigns[1].InnerWitness, assigns[1].Publics
+	if test.IsSolved(outer, &assign, eonark.FIELD) == nil {
+		t.Fatal("outer circuit solved for the public inputs of another proof")
+	}
+}
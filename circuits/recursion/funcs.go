@@ -9,6 +9,7 @@ import (
 	"github.com/consensys/gnark/std/math/bits"
 	"github.com/consensys/gnark/std/math/emulated"
 
+	"github.com/eon-protocol/eonark"
 	"github.com/eon-protocol/eonark/circuits/hasher"
 
 	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
@@ -92,13 +93,23 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) hashCommitmentByGenericHint(
 	if err != nil {
 		return nil, err
 	}
+	d, err := v.decomposeCommitmentByGenericHint(c)
+	if err != nil {
+		return nil, err
+	}
+	return h.HashG1Vars(hasher.G1DecomposedVars{XQ: d.XQ, XM: d.XM, YQ: d.YQ, YM: d.YM}), nil
+}
 
+// ---------- Core：decompose a G1 commitment into (XQ, XM, YQ, YM), bound to the point ----------
+func (v *Verifier[FR, G1El, G2El, GtEl]) decomposeCommitmentByGenericHint(
+	c kzg.Commitment[G1El],
+) (G1Decomp, error) {
 	switch p := any(c.G1El).(type) {
 	case sw_bls12381.G1Affine:
 		// ---- 1) Fp（BLS12-381 base field）
 		fp, err := emulated.NewField[sw_bls12381.BaseField](v.api)
 		if err != nil {
-			return nil, err
+			return G1Decomp{}, err
 		}
 		const baseFieldBytes = 48 // bls12-381 Fp ≈ 381 bits
 
@@ -129,7 +140,7 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) hashCommitmentByGenericHint(
 		// ---- 4) call Hint
 		outs, err := v.api.Compiler().NewHint(hasher.HintDecomposeMod_LE, 4, ins...)
 		if err != nil {
-			return nil, fmt.Errorf("hint decompose (mod bytes): %w", err)
+			return G1Decomp{}, fmt.Errorf("hint decompose (mod bytes): %w", err)
 		}
 		XQ, XM, YQ, YM := outs[0], outs[1], outs[2], outs[3]
 
@@ -154,12 +165,52 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) hashCommitmentByGenericHint(
 		fp.AssertIsEqual(&p.X, fp.Add(xmFP, fp.Mul(Mfp, xqFP)))
 		fp.AssertIsEqual(&p.Y, fp.Add(ymFP, fp.Mul(Mfp, yqFP)))
 
-		// ---- 6) hash G1 point
-		return h.HashG1Vars(hasher.G1DecomposedVars{
-			XQ: XQ, XM: XM, YQ: YQ, YM: YM,
-		}), nil
+		return G1Decomp{XQ: XQ, XM: XM, YQ: YQ, YM: YM}, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported curve element %T (expected bls12-381)", p)
+		return G1Decomp{}, fmt.Errorf("unsupported curve element %T (expected bls12-381)", p)
+	}
+}
+
+// DeriveFSInputs builds the Poseidon2-FS inputs of a single proof inside the
+// circuit. Every G1 point is decomposed with the hint above and bound to the
+// in-circuit point, so the inputs can differ per proof and need no extra witness.
+func (v *Verifier[FR, G1El, G2El, GtEl]) DeriveFSInputs(vk VerifyingKey[FR, G1El, G2El], proof Proof[FR, G1El, G2El], witness Witness[FR]) (*FSInputs, error) {
+	fs := FSInputs{
+		CIDGamma: eonark.CID_GAMMA.String(),
+		CIDBeta:  eonark.CID_BETA.String(),
+		CIDAlpha: eonark.CID_ALPHA.String(),
+		CIDZeta:  eonark.CID_ZETA.String(),
+		Qc:       make([]G1Decomp, len(vk.Qcp)),
+		BSB:      make([]G1Decomp, len(proof.Bsb22Commitments)),
+		Publics:  make([]frontend.Variable, len(witness.Public)),
+	}
+	type point struct {
+		dst *G1Decomp
+		src kzg.Commitment[G1El]
+	}
+	points := []point{
+		{&fs.S[0], vk.S[0]}, {&fs.S[1], vk.S[1]}, {&fs.S[2], vk.S[2]},
+		{&fs.Ql, vk.Ql}, {&fs.Qr, vk.Qr}, {&fs.Qm, vk.Qm}, {&fs.Qo, vk.Qo}, {&fs.Qk, vk.Qk},
+		{&fs.W[0], proof.LRO[0]}, {&fs.W[1], proof.LRO[1]}, {&fs.W[2], proof.LRO[2]},
+		{&fs.Z, proof.Z},
+		{&fs.H[0], proof.H[0]}, {&fs.H[1], proof.H[1]}, {&fs.H[2], proof.H[2]},
+	}
+	for i := range vk.Qcp {
+		points = append(points, point{&fs.Qc[i], vk.Qcp[i]})
+	}
+	for i := range proof.Bsb22Commitments {
+		points = append(points, point{&fs.BSB[i], proof.Bsb22Commitments[i]})
+	}
+	for i, p := range points {
+		d, err := v.decomposeCommitmentByGenericHint(p.src)
+		if err != nil {
+			return nil, fmt.Errorf("decompose point %d: %w", i, err)
+		}
+		*p.dst = d
+	}
+	for i := range witness.Public {
+		fs.Publics[i] = v.api.FromBinary(v.scalarApi.ToBits(&witness.Public[i])...)
 	}
+	return &fs, nil
 }
@@ -0,0 +1,161 @@
+package recursion
+
+import (
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bls12381"
+	"github.com/consensys/gnark/std/commitments/kzg"
+	"github.com/consensys/gnark/std/math/emulated"
+
+	"github.com/eon-protocol/eonark"
+)
+
+// FR, G1, G2 and GT instantiate the verifier for eonark proofs, BLS12-381 in
+// BLS12-381.
+type (
+	FR = sw_bls12381.ScalarField
+	G1 = sw_bls12381.G1Affine
+	G2 = sw_bls12381.G2Affine
+	GT = sw_bls12381.GTEl
+)
+
+// ValueOfEonarkVerifyingKey returns the in-circuit verifying key of vk.
+func ValueOfEonarkVerifyingKey(vk eonark.Vk) (VerifyingKey[FR, G1, G2], error) {
+	return ValueOfVerifyingKey[FR, G1, G2](vk.ToGnarkVerifyingKey())
+}
+
+// ValueOfEonarkProof returns the in-circuit proof of proof.
+func ValueOfEonarkProof(proof *eonark.Proof) (Proof[FR, G1, G2], error) {
+	return ValueOfProof[FR, G1, G2](proof.ToGnarkPRoof())
+}
+
+// ValueOfEonarkWitness returns the in-circuit public witness of publics.
+func ValueOfEonarkWitness(publics [eonark.NUM_PUBLIC]fr.Element) Witness[FR] {
+	ret := Witness[FR]{Public: make([]emulated.Element[FR], len(publics))}
+	for i := range publics {
+		ret.Public[i] = emulated.ValueOf[FR](publics[i].BigInt(new(big.Int)))
+	}
+	return ret
+}
+
+// ValueOfFSInputs returns the Poseidon2-FS inputs of proof of vk for publics,
+// in the order the native verifier hashes them.
+func ValueOfFSInputs(vk eonark.Vk, proof *eonark.Proof, publics [eonark.NUM_PUBLIC]fr.Element) (FSInputs, error) {
+	if vk.NP != eonark.NUM_PUBLIC {
+		return FSInputs{}, fmt.Errorf("number of public inputs is %d not %d", vk.NP, eonark.NUM_PUBLIC)
+	}
+	if len(proof.BSB) != len(vk.QC) {
+		return FSInputs{}, fmt.Errorf("number of commitments is %d not %d", len(proof.BSB), len(vk.QC))
+	}
+	fs := FSInputs{
+		CIDGamma: eonark.CID_GAMMA.String(),
+		CIDBeta:  eonark.CID_BETA.String(),
+		CIDAlpha: eonark.CID_ALPHA.String(),
+		CIDZeta:  eonark.CID_ZETA.String(),
+		S:        [3]G1Decomp{decomposeG1(vk.S1), decomposeG1(vk.S2), decomposeG1(vk.S3)},
+		Ql:       decomposeG1(vk.QL),
+		Qr:       decomposeG1(vk.QR),
+		Qm:       decomposeG1(vk.QM),
+		Qo:       decomposeG1(vk.QO),
+		Qk:       decomposeG1(vk.QK),
+		Qc:       make([]G1Decomp, len(vk.QC)),
+		W:        [3]G1Decomp{decomposeG1(proof.CW1), decomposeG1(proof.CW2), decomposeG1(proof.CW3)},
+		BSB:      make([]G1Decomp, len(proof.BSB)),
+		Z:        decomposeG1(proof.CPZ),
+		H:        [3]G1Decomp{decomposeG1(proof.CH1), decomposeG1(proof.CH2), decomposeG1(proof.CH3)},
+		Publics:  make([]frontend.Variable, len(publics)),
+	}
+	for i := range vk.QC {
+		fs.Qc[i] = decomposeG1(vk.QC[i])
+		fs.BSB[i] = decomposeG1(proof.BSB[i])
+	}
+	for i := range publics {
+		fs.Publics[i] = publics[i].String()
+	}
+	return fs, nil
+}
+
+func decomposeG1(val bls12381.G1Affine) G1Decomp {
+	d := eonark.DecomposeG1(val)
+	return G1Decomp{XQ: d[0][0].String(), XM: d[0][1].String(), YQ: d[1][0].String(), YM: d[1][1].String()}
+}
+
+// OuterCircuit verifies one proof of an eonark key of NUM_PUBLIC public
+// inputs and exposes them as its own. The key is compiled in as a constant and
+// the FS inputs are derived from the proof, so the circuit of a key verifies
+// any of its proofs.
+type OuterCircuit struct {
+	Proof        Proof[FR, G1, G2]
+	VerifyingKey VerifyingKey[FR, G1, G2] `gnark:"-"`
+	InnerWitness Witness[FR]
+	Publics      [eonark.NUM_PUBLIC]frontend.Variable `gnark:",public"`
+}
+
+// NewOuterCircuit returns the outer circuit to compile for the proofs of vk.
+func NewOuterCircuit(vk eonark.Vk) (*OuterCircuit, error) {
+	if vk.NP != eonark.NUM_PUBLIC {
+		return nil, fmt.Errorf("number of public inputs is %d not %d", vk.NP, eonark.NUM_PUBLIC)
+	}
+	cvk, err := ValueOfEonarkVerifyingKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	return &OuterCircuit{
+		Proof: Proof[FR, G1, G2]{
+			BatchedProof:     kzg.BatchOpeningProof[FR, G1]{ClaimedValues: make([]emulated.Element[FR], 6+len(vk.QC))},
+			Bsb22Commitments: make([]kzg.Commitment[G1], len(vk.QC)),
+		},
+		VerifyingKey: cvk,
+		InnerWitness: Witness[FR]{Public: make([]emulated.Element[FR], eonark.NUM_PUBLIC)},
+	}, nil
+}
+
+// AssignOuterCircuit returns the assignment of the outer circuit of vk for
+// proof of publics.
+func AssignOuterCircuit(vk eonark.Vk, proof *eonark.Proof, publics [eonark.NUM_PUBLIC]fr.Element) (*OuterCircuit, error) {
+	if vk.NP != eonark.NUM_PUBLIC {
+		return nil, fmt.Errorf("number of public inputs is %d not %d", vk.NP, eonark.NUM_PUBLIC)
+	}
+	if len(proof.BSB) != len(vk.QC) {
+		return nil, fmt.Errorf("number of commitments is %d not %d", len(proof.BSB), len(vk.QC))
+	}
+	cvk, err := ValueOfEonarkVerifyingKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	cproof, err := ValueOfEonarkProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	ret := OuterCircuit{
+		Proof:        cproof,
+		VerifyingKey: cvk,
+		InnerWitness: ValueOfEonarkWitness(publics),
+	}
+	for i := range publics {
+		ret.Publics[i] = publics[i]
+	}
+	return &ret, nil
+}
+
+func (c *OuterCircuit) Define(api frontend.API) error {
+	v, err := NewVerifier[FR, G1, G2, GT](api)
+	if err != nil {
+		return err
+	}
+	if err := v.AssertProof(c.VerifyingKey, c.Proof, c.InnerWitness, WithCompleteArithmetic(), WithDerivedFSInputs()); err != nil {
+		return err
+	}
+	f, err := emulated.NewField[FR](api)
+	if err != nil {
+		return err
+	}
+	for i := range c.Publics {
+		api.AssertIsEqual(c.Publics[i], api.FromBinary(f.ToBits(&c.InnerWitness.Public[i])...))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package recursion
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+
+	"github.com/eon-protocol/eonark"
+)
+
+// fsCircuit asserts that the FS inputs derived in-circuit from the proof are FS.
+type fsCircuit struct {
+	VerifyingKey VerifyingKey[FR, G1, G2] `gnark:"-"`
+	Proof        Proof[FR, G1, G2]
+	Witness      Witness[FR]
+	FS           FSInputs `gnark:"-"`
+}
+
+func (c *fsCircuit) Define(api frontend.API) error {
+	v, err := NewVerifier[FR, G1, G2, GT](api)
+	if err != nil {
+		return err
+	}
+	fs, err := v.DeriveFSInputs(c.VerifyingKey, c.Proof, c.Witness)
+	if err != nil {
+		return err
+	}
+	got := append([]G1Decomp{fs.S[0], fs.S[1], fs.S[2], fs.Ql, fs.Qr, fs.Qm, fs.Qo, fs.Qk, fs.W[0], fs.W[1], fs.W[2], fs.Z, fs.H[0], fs.H[1], fs.H[2]}, append(fs.Qc, fs.BSB...)...)
+	want := append([]G1Decomp{c.FS.S[0], c.FS.S[1], c.FS.S[2], c.FS.Ql, c.FS.Qr, c.FS.Qm, c.FS.Qo, c.FS.Qk, c.FS.W[0], c.FS.W[1], c.FS.W[2], c.FS.Z, c.FS.H[0], c.FS.H[1], c.FS.H[2]}, append(c.FS.Qc, c.FS.BSB...)...)
+	for i := range want {
+		api.AssertIsEqual(got[i].XQ, want[i].XQ)
+		api.AssertIsEqual(got[i].XM, want[i].XM)
+		api.AssertIsEqual(got[i].YQ, want[i].YQ)
+		api.AssertIsEqual(got[i].YM, want[i].YM)
+	}
+	for i := range c.FS.Publics {
+		api.AssertIsEqual(fs.Publics[i], c.FS.Publics[i])
+	}
+	return nil
+}
+
+func TestValueOfFSInputs(t *testing.T) {
+	innerCS, err := frontend.Compile(eonark.FIELD, scs.NewBuilder, &innerCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pk eonark.Pk
+	useTestSRS(t, &pk, innerCS)
+	if err := pk.Compile(&innerCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	publics, _, proof, err := pk.Prove(&innerCircuit{X: 1, Y: 2, Z: 3, W: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	outer, err := NewOuterCircuit(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assign, err := AssignOuterCircuit(vk, proof, [eonark.NUM_PUBLIC]fr.Element(publics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outer.Proof.Bsb22Commitments) != len(vk.QC) || assign.Publics[3] != publics[3] {
+		t.Fatal("outer circuit not sized for the key")
+	}
+	fs, err := ValueOfFSInputs(vk, proof, [eonark.NUM_PUBLIC]fr.Element(publics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.Qc) != len(vk.QC) {
+		t.Fatal("FS inputs not sized for the key")
+	}
+	circuit := fsCircuit{VerifyingKey: outer.VerifyingKey, Proof: outer.Proof, Witness: outer.InnerWitness, FS: fs}
+	assignment := fsCircuit{VerifyingKey: assign.VerifyingKey, Proof: assign.Proof, Witness: assign.InnerWitness, FS: fs}
+	if err := test.IsSolved(&circuit, &assignment, eonark.FIELD); err != nil {
+		t.Fatal(err)
+	}
+	// the FS inputs of other public inputs are not those of the proof
+	publics[1].SetUint64(5)
+	if assignment.FS, err = ValueOfFSInputs(vk, proof, [eonark.NUM_PUBLIC]fr.Element(publics)); err != nil {
+		t.Fatal(err)
+	}
+	circuit.FS = assignment.FS
+	if test.IsSolved(&circuit, &assignment, eonark.FIELD) == nil {
+		t.Fatal("FS inputs of other public inputs accepted")
+	}
+}
@@ -76,6 +76,7 @@ func GetNativeVerifierOptions(outer, field *big.Int) backend.VerifierOption {
 type verifierCfg struct {
 	withCompleteArithmetic bool
 	fsIn                   *FSInputs // optional, used for Poseidon2-FS branch
+	deriveFS               bool      // build fsIn per proof from the in-circuit points
 }
 
 // VerifierOption allows to modify the behaviour of PLONK verifier.
@@ -111,3 +112,14 @@ func WithFSInputs(in *FSInputs) VerifierOption {
 		return nil
 	}
 }
+
+// WithDerivedFSInputs selects the Poseidon2-FS branch with inputs derived from
+// the verified proof and key themselves (see [Verifier.DeriveFSInputs]). Unlike
+// [WithFSInputs] it is safe to pass to [Verifier.AssertSameProofs] and
+// [Verifier.AssertDifferentProofs], where every proof has its own transcript.
+func WithDerivedFSInputs() VerifierOption {
+	return func(c *verifierCfg) error {
+		c.deriveFS = true
+		return nil
+	}
+}
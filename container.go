@@ -0,0 +1,155 @@
+package eonark
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Framed layout: magic | version u16 | curve u16 | kind u8 | length u64 | sha256 | payload.
+// The magic starts below 0x80 while a compressed G1 point always starts at or
+// above it, so the bare layout written by earlier releases is still readable
+// and is reported as version 0. Version 2 adds the number of public inputs to
+// verifying keys, older ones have NUM_PUBLIC. Version 3 stores any number of
+// BSB22 commitments in keys and proofs, older ones have exactly one.
+const CONTAINER_MAGIC = "EONK"
+const CONTAINER_VERSION = 3
+const CONTAINER_HEADER_SIZE = len(CONTAINER_MAGIC) + 2 + 2 + 1 + 8 + sha256.Size
+
+type Kind uint8
+
+const (
+	KIND_VK Kind = iota + 1
+	KIND_PROOF
+	KIND_PK
+)
+
+func (me Kind) String() string {
+	switch me {
+	case KIND_VK:
+		return "vk"
+	case KIND_PROOF:
+		return "proof"
+	case KIND_PK:
+		return "pk"
+	default:
+		return fmt.Sprintf("kind(%d)", uint8(me))
+	}
+}
+
+var ErrUnsupportedVersion = errors.New("unsupported container version")
+var ErrCurveMismatch = errors.New("container curve mismatch")
+var ErrKindMismatch = errors.New("container kind mismatch")
+var ErrChecksumMismatch = errors.New("container checksum mismatch")
+var ErrTrailingData = errors.New("container has trailing data")
+
+type VersionError struct {
+	Version uint16
+}
+
+func (me *VersionError) Error() string {
+	return fmt.Sprintf("%v: %d > %d", ErrUnsupportedVersion, me.Version, CONTAINER_VERSION)
+}
+
+func (me *VersionError) Unwrap() error {
+	return ErrUnsupportedVersion
+}
+
+type CurveError struct {
+	Curve ecc.ID
+}
+
+func (me *CurveError) Error() string {
+	return fmt.Sprintf("%v: %v != %v", ErrCurveMismatch, me.Curve, ecc.BLS12_381)
+}
+
+func (me *CurveError) Unwrap() error {
+	return ErrCurveMismatch
+}
+
+type KindError struct {
+	Want, Got Kind
+}
+
+func (me *KindError) Error() string {
+	return fmt.Sprintf("%v: want %v, got %v", ErrKindMismatch, me.Want, me.Got)
+}
+
+func (me *KindError) Unwrap() error {
+	return ErrKindMismatch
+}
+
+func writeContainer(w io.Writer, kind Kind, raw func(io.Writer) (int64, error)) (int64, error) {
+	var payload bytes.Buffer
+	if _, err := raw(&payload); err != nil {
+		return 0, err
+	}
+	sum := sha256.Sum256(payload.Bytes())
+	header := make([]byte, 0, CONTAINER_HEADER_SIZE)
+	header = append(header, CONTAINER_MAGIC...)
+	header = binary.BigEndian.AppendUint16(header, CONTAINER_VERSION)
+	header = binary.BigEndian.AppendUint16(header, uint16(ecc.BLS12_381))
+	header = append(header, byte(kind))
+	header = binary.BigEndian.AppendUint64(header, uint64(payload.Len()))
+	header = append(header, sum[:]...)
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := payload.WriteTo(w)
+	return int64(n) + m, err
+}
+
+func readContainer(r io.Reader, kind Kind, raw func(io.Reader, uint16) (int64, error)) (int64, error) {
+	magic := make([]byte, len(CONTAINER_MAGIC))
+	n, err := io.ReadFull(r, magic)
+	if err != nil {
+		return int64(n), err
+	}
+	if string(magic) != CONTAINER_MAGIC {
+		m, err := raw(io.MultiReader(bytes.NewReader(magic), r), 0)
+		return m, err
+	}
+	header := make([]byte, CONTAINER_HEADER_SIZE-len(CONTAINER_MAGIC))
+	m, err := io.ReadFull(r, header)
+	if err != nil {
+		return int64(n + m), err
+	}
+	read := int64(n + m)
+	version := binary.BigEndian.Uint16(header[0:])
+	if version == 0 || version > CONTAINER_VERSION {
+		return read, &VersionError{version}
+	}
+	if curve := ecc.ID(binary.BigEndian.Uint16(header[2:])); curve != ecc.BLS12_381 {
+		return read, &CurveError{curve}
+	}
+	if got := Kind(header[4]); got != kind {
+		return read, &KindError{kind, got}
+	}
+	size := binary.BigEndian.Uint64(header[5:])
+	sum := header[13:]
+	hasher := sha256.New()
+	payload := io.LimitReader(r, int64(size))
+	p, err := raw(io.TeeReader(payload, hasher), version)
+	read += p
+	if err != nil {
+		return read, err
+	}
+	rest, err := io.Copy(hasher, payload)
+	read += rest
+	if err != nil {
+		return read, err
+	}
+	if !bytes.Equal(hasher.Sum(nil), sum) {
+		return read, ErrChecksumMismatch
+	}
+	if rest != 0 {
+		return read, ErrTrailingData
+	}
+	return read, nil
+}
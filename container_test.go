@@ -0,0 +1,118 @@
+package eonark_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
+
+func TestContainer(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 6))
+	if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	vk.SetTestSRS(nil)
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	framed := buf.Bytes()
+	// header offsets: magic | version | curve | kind | length | sha256
+	const version, curve, kind, length, sum = 4, 6, 8, 9, 17
+	resum := func(b []byte) {
+		s := sha256.Sum256(b[eonark.CONTAINER_HEADER_SIZE:])
+		copy(b[sum:], s[:])
+	}
+	for name, v := range map[string]struct {
+		tamper func([]byte) []byte
+		want   error
+		check  func(error) bool
+	}{
+		"version 0": {func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[version:], 0)
+			return b
+		}, eonark.ErrUnsupportedVersion, func(err error) bool {
+			var e *eonark.VersionError
+			return errors.As(err, &e) && e.Version == 0
+		}},
+		"newer version": {func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[version:], eonark.CONTAINER_VERSION+1)
+			return b
+		}, eonark.ErrUnsupportedVersion, func(err error) bool {
+			var e *eonark.VersionError
+			return errors.As(err, &e) && e.Version == eonark.CONTAINER_VERSION+1
+		}},
+		"other curve": {func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[curve:], uint16(ecc.BN254))
+			return b
+		}, eonark.ErrCurveMismatch, func(err error) bool {
+			var e *eonark.CurveError
+			return errors.As(err, &e) && e.Curve == ecc.BN254
+		}},
+		"other kind": {func(b []byte) []byte {
+			b[kind] = byte(eonark.KIND_PROOF)
+			return b
+		}, eonark.ErrKindMismatch, func(err error) bool {
+			var e *eonark.KindError
+			return errors.As(err, &e) && e.Want == eonark.KIND_VK && e.Got == eonark.KIND_PROOF
+		}},
+		"wrong checksum": {func(b []byte) []byte {
+			b[sum] ^= 1
+			return b
+		}, eonark.ErrChecksumMismatch, nil},
+		"appended byte": {func(b []byte) []byte {
+			b = append(b, 0)
+			binary.BigEndian.PutUint64(b[length:], binary.BigEndian.Uint64(b[length:])+1)
+			return b
+		}, eonark.ErrChecksumMismatch, nil},
+		"trailing data": {func(b []byte) []byte {
+			b = append(b, 0)
+			binary.BigEndian.PutUint64(b[length:], binary.BigEndian.Uint64(b[length:])+1)
+			resum(b)
+			return b
+		}, eonark.ErrTrailingData, nil},
+	} {
+		var read eonark.Vk
+		_, err := read.ReadFrom(bytes.NewReader(v.tamper(slices.Clone(framed))))
+		if !errors.Is(err, v.want) || (v.check != nil && !v.check(err)) {
+			t.Errorf("%s: got %v, want %v", name, err, v.want)
+		}
+	}
+
+	// a proof is not read as a key
+	var proof eonark.Proof
+	if _, err := proof.ReadFrom(bytes.NewReader(framed)); !errors.Is(err, eonark.ErrKindMismatch) {
+		t.Fatalf("vk read as a proof: %v", err)
+	}
+
+	// keys written before the container are read as version 0
+	var legacy bytes.Buffer
+	enc := bls12381.NewEncoder(&legacy)
+	for _, p := range []*bls12381.G1Affine{&vk.S1, &vk.S2, &vk.S3, &vk.QL, &vk.QR, &vk.QM, &vk.QO, &vk.QK, &vk.QC[0]} {
+		if err := enc.Encode(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	legacy.Write(binary.BigEndian.AppendUint32(nil, vk.CI[0]))
+	legacy.WriteByte(vk.SZ)
+	var read eonark.Vk
+	if _, err := read.ReadFrom(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	want := vk
+	want.NP = eonark.NUM_PUBLIC
+	if !read.Equal(&want) {
+		t.Fatal("legacy vk changed")
+	}
+}
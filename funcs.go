@@ -8,127 +8,107 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math/big"
 	"math/bits"
-	"net/http"
 	"os"
 	"path"
-	"sync"
 
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
-	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
 	"github.com/consensys/gnark/logger"
-	"github.com/schollz/progressbar/v3"
-)
 
-var permutation = sync.OnceValue(func() *poseidon2.Permutation {
-	return poseidon2.NewPermutationWithSeed(HASH_T, HASH_RF, HASH_RP, "EON_POSEIDON2_HASH_SEED")
-})
+	"github.com/eon-protocol/eonark/zkcore"
+)
 
 func DecomposeG1(val bls12381.G1Affine) [2][2]fr.Element {
-	var ixq, ixm, iyq, iym big.Int
-	var exq, exm, eyq, eym fr.Element
-	val.X.BigInt(&ixq)
-	val.Y.BigInt(&iyq)
-	ixq.DivMod(&ixq, fr.Modulus(), &ixm)
-	iyq.DivMod(&iyq, fr.Modulus(), &iym)
-	exq.SetBigInt(&ixq)
-	exm.SetBigInt(&ixm)
-	eyq.SetBigInt(&iyq)
-	eym.SetBigInt(&iym)
-	return [2][2]fr.Element{{exq, exm}, {eyq, eym}}
+	return zkcore.DecomposeG1(val)
 }
 
 func HashG1(val bls12381.G1Affine) fr.Element {
-	decompose := DecomposeG1(val)
-	x := HashCompress(decompose[0][0], decompose[0][1])
-	y := HashCompress(decompose[1][0], decompose[1][1])
-	return HashCompress(x, y)
+	return zkcore.HashG1(val)
 }
 
 func HashCompress(x, y fr.Element) fr.Element {
-	vars := [2]fr.Element{x, y}
-	if err := permutation().Permutation(vars[:]); err != nil {
-		log.Fatalln(err)
-	}
-	var ret fr.Element
-	ret.Add(&vars[1], &y)
-	return ret
+	return zkcore.HashCompress(x, y)
 }
 
 func HashSum(val ...fr.Element) fr.Element {
-	var ret fr.Element
-	for _, v := range val {
-		ret = HashCompress(ret, v)
-	}
-	return ret
+	return zkcore.HashSum(val...)
 }
 
 func ParseProvingKey(bytepk []byte, size int) (val []bls12381.G1Affine, err error) {
-	var g1 bls12381.G1Affine
-	buf := make([]byte, 96)
-	reader := bytes.NewReader(bytepk)
-	val = make([]bls12381.G1Affine, 0, size)
-	for i := 0; i < size; i++ {
-		if _, err = io.ReadFull(reader, buf); err != nil {
-			return
-		}
-		g1.X.SetBytes(buf[:48])
-		g1.Y.SetBytes(buf[48:])
-		val = append(val, g1)
-	}
-	return
+	return parseSRS(bytes.NewReader(bytepk), size)
 }
 
 func ReadProvingKey(sc, sl int) (ck kzg.ProvingKey, lk kzg.ProvingKey, err error) {
-	logsl := bits.TrailingZeros(uint(sl))
-	if bits.OnesCount(uint(sl)) != 1 || logsl >= len(SRS_LK_HASH) {
-		err = errors.New("invalid sl")
+	return ReadProvingKeyFrom(SRS_SOURCE, sc, sl)
+}
+
+func ReadProvingKeyFrom(src SRSSource, sc, sl int) (ck kzg.ProvingKey, lk kzg.ProvingKey, err error) {
+	if err = checkSRSLKSize(sl); err != nil {
 		return
 	}
-	pathck := path.Join(DATA_CACHE_DIR, "SRS.CK.BIN")
-	pathlk := path.Join(DATA_CACHE_DIR, fmt.Sprintf("SRS.LK.%v.BIN", logsl))
-	byteck, errck := os.ReadFile(pathck)
-	sumck := sha256.Sum256(byteck)
-	sumckstr := hex.EncodeToString(sumck[:])
-	if errck != nil || sumckstr != SRS_CK_HASH {
-		log.Println("local srsck cache not found; downloading ...")
-		if byteck, err = download_srs_ck(pathck); err != nil {
-			return
-		}
-	}
-	if ck.G1, err = ParseProvingKey(byteck, sc); err != nil {
+	if ck.G1, err = readSRSCK(src, sc); err != nil {
 		return
 	}
-	bytelk, errlk := os.ReadFile(pathlk)
-	sumlk := sha256.Sum256(bytelk)
-	sumlkstr := hex.EncodeToString(sumlk[:])
-	if errlk != nil || sumlkstr != SRS_LK_HASH[logsl] {
+	lk.G1, err = readSRSLK(ck.G1, sl)
+	return
+}
+
+func checkSRSLKSize(sl int) error {
+	if bits.OnesCount(uint(sl)) != 1 || bits.TrailingZeros(uint(sl)) >= len(SRS_LK_HASH) {
+		return errors.New("invalid sl")
+	}
+	return nil
+}
+
+func readSRSCK(src SRSSource, sc int) ([]bls12381.G1Affine, error) {
+	fileck, err := src.OpenCK()
+	if err != nil {
+		return nil, err
+	}
+	defer fileck.Close()
+	if err := CheckSRSCKPrefix(fileck, sc); err != nil {
+		return nil, err
+	}
+	ck, err := parseSRS(fileck, sc)
+	if err == nil && SRS_VERIFY {
+		err = VerifySRS(ck)
+	}
+	return ck, err
+}
+
+func readSRSLK(ck []bls12381.G1Affine, sl int) ([]bls12381.G1Affine, error) {
+	logsl := bits.TrailingZeros(uint(sl))
+	pathlk := path.Join(DATA_CACHE_DIR, fmt.Sprintf("SRS.LK.%v.BIN", logsl))
+	lk, err := read_srs_lk(pathlk, sl, SRS_LK_HASH[logsl])
+	if err != nil {
 		log.Println("local srslk cache not found; generating ...")
-		lk.G1, err = generate_srs_lk(pathlk, ck.G1[:sl])
-		return
+		lk, err = generate_srs_lk(pathlk, ck[:sl])
 	}
-	if lk.G1, err = ParseProvingKey(bytelk, sl); err != nil {
-		return
+	if err == nil && SRS_VERIFY {
+		err = verifySRSLK(ck, lk)
 	}
-	return
+	return lk, err
 }
 
-func download_srs_ck(pathck string) ([]byte, error) {
-	resp, err := http.Get(SRS_DOWNLOAD_URL)
+func read_srs_lk(pathlk string, sl int, sum string) ([]bls12381.G1Affine, error) {
+	filelk, err := openSRSFile(pathlk)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	var buf bytes.Buffer
-	bar := progressbar.DefaultBytes(resp.ContentLength, "Downloading SRSCK")
-	if _, err := io.Copy(io.MultiWriter(&buf, bar), resp.Body); err != nil {
+	defer filelk.Close()
+	if filelk.Size() != int64(sl)*96 {
+		return nil, errors.New("invalid srslk size")
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, io.NewSectionReader(filelk, 0, filelk.Size())); err != nil {
 		return nil, err
 	}
-	byteck := buf.Bytes()
-	return byteck, os.WriteFile(pathck, byteck, 0o644)
+	if hex.EncodeToString(hasher.Sum(nil)) != sum {
+		return nil, errors.New("invalid srslk sha256")
+	}
+	return parseSRS(filelk, sl)
 }
 
 func generate_srs_lk(pathlk string, g1 []bls12381.G1Affine) ([]bls12381.G1Affine, error) {
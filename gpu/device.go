@@ -3,6 +3,7 @@
 package gpu
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -10,26 +11,76 @@ import (
 	icicle_runtime "github.com/ingonyama-zk/icicle-gnark/v3/wrappers/golang/runtime"
 )
 
-var onceWarmUpDevice sync.Once
+var onceWarmUpDevice sync.Map
+var deviceLocks sync.Map
 
-func warmUpDevice() {
-	onceWarmUpDevice.Do(func() {
+type warmUp struct {
+	once sync.Once
+	err  error
+}
+
+// deviceLock serializes the proofs on one device, whatever their proving key,
+// and remembers the NTT domain set on it.
+type deviceLock struct {
+	sem    chan struct{}
+	domain uint64
+}
+
+func deviceLockOf(dev Device) *deviceLock {
+	lock, _ := deviceLocks.LoadOrStore(dev, &deviceLock{sem: make(chan struct{}, 1)})
+	return lock.(*deviceLock)
+}
+
+func lockDevice(ctx context.Context, dev Device) (*deviceLock, error) {
+	lock := deviceLockOf(dev)
+	select {
+	case lock.sem <- struct{}{}:
+		return lock, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (me *deviceLock) Unlock() {
+	<-me.sem
+}
+
+// deviceError returns nil on success and a *DeviceError otherwise.
+func deviceError(op string, dev Device, st icicle_runtime.EIcicleError) error {
+	if st == icicle_runtime.Success {
+		return nil
+	}
+	return &DeviceError{Op: op, Device: dev, Code: int(st), Reason: st.AsString()}
+}
+
+func warmUpDevice(dev Device) error {
+	v, _ := onceWarmUpDevice.LoadOrStore(dev, new(warmUp))
+	w := v.(*warmUp)
+	w.once.Do(func() {
 		log := logger.Logger()
-		err := icicle_runtime.LoadBackendFromEnvOrDefault()
-		if err != icicle_runtime.Success {
-			panic(fmt.Sprintf("ICICLE backend loading error: %s", err.AsString()))
+		if w.err = deviceError("load backend", dev, icicle_runtime.LoadBackendFromEnvOrDefault()); w.err != nil {
+			return
+		}
+		device := icicle_runtime.CreateDevice(dev.Type, dev.Id)
+		if !icicle_runtime.IsDeviceAvailable(&device) {
+			w.err = &DeviceError{Op: "create device", Device: dev, Code: int(icicle_runtime.InvalidDevice), Reason: "device is not available"}
+			return
 		}
-		device := icicle_runtime.CreateDevice("CUDA", 0)
 		log.Debug().Int32("id", device.Id).Str("type", device.GetDeviceType()).Msg("ICICLE device created")
+		done := make(chan struct{})
 		icicle_runtime.RunOnDevice(&device, func(args ...any) {
-			stream, err := icicle_runtime.CreateStream()
-			if err != icicle_runtime.Success {
-				panic(fmt.Sprintf("ICICLE create stream error: %s", err.AsString()))
-			}
-			err = icicle_runtime.WarmUpDevice(stream)
-			if err != icicle_runtime.Success {
-				panic(fmt.Sprintf("ICICLE device warmup error: %s", err.AsString()))
+			defer close(done)
+			stream, st := icicle_runtime.CreateStream()
+			if w.err = deviceError("create stream", dev, st); w.err != nil {
+				return
 			}
+			defer icicle_runtime.DestroyStream(stream)
+			w.err = deviceError("warm up", dev, icicle_runtime.WarmUpDevice(stream))
 		})
+		<-done
 	})
+	if w.err != nil {
+		return fmt.Errorf("ICICLE device warmup: %w", w.err)
+	}
+	return nil
 }
@@ -0,0 +1,16 @@
+package gpu
+
+import "fmt"
+
+// DeviceError is a failed ICICLE step of a proof. Code is the ICICLE
+// eIcicleError code, Reason its name or a description of a failed check.
+type DeviceError struct {
+	Op     string
+	Device Device
+	Code   int
+	Reason string
+}
+
+func (me *DeviceError) Error() string {
+	return fmt.Sprintf("icicle %s on %s: %s (code %d)", me.Op, me.Device, me.Reason, me.Code)
+}
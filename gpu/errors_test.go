@@ -0,0 +1,21 @@
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDeviceError(t *testing.T) {
+	err := fmt.Errorf("icicle device setup: %w", &DeviceError{Op: "InitDomain", Device: Device{"CUDA", 2}, Code: 11, Reason: "EIcicleError.InvalidArgument"})
+	var derr *DeviceError
+	if !errors.As(err, &derr) {
+		t.Fatal("DeviceError not found")
+	}
+	if derr.Device.Id != 2 || derr.Code != 11 {
+		t.Fatalf("got %+v", derr)
+	}
+	if expected := "icicle device setup: icicle InitDomain on CUDA:2: EIcicleError.InvalidArgument (code 11)"; err.Error() != expected {
+		t.Fatalf("got %q", err.Error())
+	}
+}
@@ -3,6 +3,8 @@
 package gpu
 
 import (
+	"context"
+
 	"github.com/consensys/gnark/backend"
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	"github.com/consensys/gnark/backend/witness"
@@ -10,5 +12,10 @@ import (
 )
 
 func Prove(spr *cs.SparseR1CS, pk *ProvingKey, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
-	return prove(spr, pk, w, opts...)
+	return prove(context.Background(), spr, pk, w, opts...)
+}
+
+// ProveContext is Prove stopping with ctx.Err() once ctx is done.
+func ProveContext(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+	return prove(ctx, spr, pk, w, opts...)
 }
@@ -3,6 +3,7 @@
 package gpu
 
 import (
+	"context"
 	"errors"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
@@ -10,6 +11,8 @@ import (
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	"github.com/consensys/gnark/backend/witness"
 	cs "github.com/consensys/gnark/constraint/bls12-381"
+
+	"github.com/eon-protocol/eonark/observer"
 )
 
 const HasIcicle = false
@@ -18,8 +21,24 @@ type ProvingKey struct {
 	Kzg         kzg.ProvingKey
 	KzgLagrange kzg.ProvingKey
 	Vk          *plonkbls12381.VerifyingKey
+	Trace       *plonkbls12381.Trace
+	Device      Device
+	Pool        *DevicePool
+	Observer    observer.ProverObserver
+}
+
+func WrapProvingKey(pk *plonkbls12381.ProvingKey) (*ProvingKey, error) {
+	return &ProvingKey{Kzg: pk.Kzg, KzgLagrange: pk.KzgLagrange, Vk: pk.Vk}, nil
+}
+
+func (pk *ProvingKey) Close() error {
+	return nil
 }
 
 func Prove(_ *cs.SparseR1CS, _ *ProvingKey, _ witness.Witness, _ ...backend.ProverOption) (*plonkbls12381.Proof, error) {
 	return nil, errors.New("icicle requested but program compiled without 'icicle' build tag")
 }
+
+func ProveContext(_ context.Context, spr *cs.SparseR1CS, pk *ProvingKey, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+	return Prove(spr, pk, w, opts...)
+}
@@ -6,12 +6,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"math/bits"
 	"runtime"
 	"sync"
-	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -23,7 +21,6 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/iop"
-	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
 	"github.com/consensys/gnark/backend"
@@ -38,6 +35,7 @@ import (
 
 	// eon "github.com/eon-protocol/eonark"
 	kzg_bls12_381 "github.com/eon-protocol/eonark/gpu/bls12381"
+	"github.com/eon-protocol/eonark/observer"
 	eon "github.com/eon-protocol/eonark/zkcore"
 
 	icicle_core "github.com/ingonyama-zk/icicle-gnark/v3/wrappers/golang/core"
@@ -83,13 +81,15 @@ const (
 	order_blinding_Z = 2
 )
 
-func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
+// setupDevicePointers uploads the SRS of pk to device and precomputes what
+// every proof of spr needs there. The NTT domain is set by initDomain.
+func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS, device Device) error {
 	// setup icicle backend
-	if st := icicle_runtime.LoadBackendFromEnvOrDefault(); st != icicle_runtime.Success {
-		return fmt.Errorf("icicle backend: %s", st.AsString())
+	if err := deviceError("load backend", device, icicle_runtime.LoadBackendFromEnvOrDefault()); err != nil {
+		return err
 	}
-	dev := icicle_runtime.CreateDevice("CUDA", 0)
-	pk.deviceInfo = &deviceInfo{Device: dev}
+	dev := icicle_runtime.CreateDevice(device.Type, device.Id)
+	pk.deviceInfo = &deviceInfo{Device: dev, device: device, lock: deviceLockOf(device)}
 
 	d0 := fft.NewDomain(uint64(spr.GetNbConstraints() + len(spr.Public)))
 	n := int(d0.Cardinality)
@@ -111,11 +111,11 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 		g1LagHost.CopyToDevice(&pk.deviceInfo.G1Device.G1Lagrange, true)
 
 		if st := icicle_bls12_381.AffineFromMontgomery(pk.deviceInfo.G1Device.G1); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("AffineFromMontgomery(G1): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("AffineFromMontgomery(G1)", st)
 			return
 		}
 		if st := icicle_bls12_381.AffineFromMontgomery(pk.deviceInfo.G1Device.G1Lagrange); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("AffineFromMontgomery(G1Lagrange): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("AffineFromMontgomery(G1Lagrange)", st)
 			return
 		}
 	})
@@ -123,28 +123,9 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 	if copyErr != nil {
 		return copyErr
 	}
+	pk.obs.Transfer(true, (len(pk.Kzg.G1)+len(pk.KzgLagrange.G1))*curve.SizeOfG1AffineUncompressed)
 
 	/***********************  Host Preparation  **************************/
-	genBits := d0.Generator.Bits()
-	limbs := icicle_core.ConvertUint64ArrToUint32Arr(genBits[:])
-	var rou icicle_bls12_381.ScalarField
-	rou = rou.FromLimbs(limbs)
-
-	var stRls icicle_runtime.EIcicleError
-	var stInit icicle_runtime.EIcicleError
-	done = make(chan struct{})
-	icicle_runtime.RunOnDevice(&pk.deviceInfo.Device, func(args ...any) {
-		defer close(done)
-		stRls = icicle_ntt.ReleaseDomain()
-		stInit = icicle_ntt.InitDomain(rou, icicle_core.GetDefaultNTTInitDomainConfig())
-	})
-	<-done
-	if stRls != icicle_runtime.Success {
-		return fmt.Errorf("ReleaseDomain failed: %s", stRls.AsString())
-	}
-	if stInit != icicle_runtime.Success {
-		return fmt.Errorf("InitDomain failed: %s", stInit.AsString())
-	}
 	pk.deviceInfo.N = n
 
 	var d1 *fft.Domain
@@ -192,11 +173,11 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 
 		// convert to "non-Montgomery" form for direct use in VecMulOnDevice
 		if st := kzg_bls12_381.MontConvOnDevice(pk.deviceInfo.CosetTable, false); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("FromMontgomery(cosetTable): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("FromMontgomery(cosetTable)", st)
 			return
 		}
 		if st := kzg_bls12_381.MontConvOnDevice(pk.deviceInfo.CosetTableRev, false); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("FromMontgomery(cosetTableRev): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("FromMontgomery(cosetTableRev)", st)
 			return
 		}
 
@@ -210,11 +191,11 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 
 		// convert to "non-Montgomery" form for direct use in NTT/INTT
 		if st := kzg_bls12_381.MontConvOnDevice(pk.deviceInfo.BigTwiddlesN, false); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("FromMontgomery(bigTwiddlesN): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("FromMontgomery(bigTwiddlesN)", st)
 			return
 		}
 		if st := kzg_bls12_381.MontConvOnDevice(pk.deviceInfo.BigTwiddlesNRev, false); st != icicle_runtime.Success {
-			copyErr = fmt.Errorf("FromMontgomery(bigTwiddlesNRev): %s", st.AsString())
+			copyErr = pk.deviceInfo.fail("FromMontgomery(bigTwiddlesNRev)", st)
 			return
 		}
 
@@ -226,6 +207,7 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 	if copyErr != nil {
 		return copyErr
 	}
+	pk.obs.Transfer(true, 4*n*fr.Bytes)
 
 	/***********************  MSM Precomputation  **************************/
 	{
@@ -271,7 +253,7 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 			<-done
 
 			if precomputeErr != icicle_runtime.Success {
-				return fmt.Errorf("MSM precompute Lagrange bases failed: %s", precomputeErr.AsString())
+				return pk.deviceInfo.fail("MSM precompute Lagrange bases", precomputeErr)
 			}
 
 			pk.deviceInfo.MsmCfgLag = cfg
@@ -325,7 +307,7 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 			<-done
 
 			if precomputeErr != icicle_runtime.Success {
-				return fmt.Errorf("MSM precompute G1 bases failed: %s", precomputeErr.AsString())
+				return pk.deviceInfo.fail("MSM precompute G1 bases", precomputeErr)
 			}
 
 			pk.deviceInfo.MsmCfgG1 = cfg
@@ -335,58 +317,108 @@ func (pk *ProvingKey) setupDevicePointers(spr *cs.SparseR1CS) error {
 
 	const numStreams = 4
 	pk.deviceInfo.Streams = make([]icicle_runtime.Stream, numStreams)
-	for i := 0; i < numStreams; i++ {
-		st := icicle_runtime.Success
-		pk.deviceInfo.Streams[i], st = icicle_runtime.CreateStream()
-		if st != icicle_runtime.Success {
-			return fmt.Errorf("CreateStream[%d]: %s", i, st.AsString())
+	done = make(chan struct{})
+	icicle_runtime.RunOnDevice(&pk.deviceInfo.Device, func(args ...any) {
+		defer close(done)
+		for i := 0; i < numStreams; i++ {
+			st := icicle_runtime.Success
+			pk.deviceInfo.Streams[i], st = icicle_runtime.CreateStream()
+			if st != icicle_runtime.Success {
+				copyErr = pk.deviceInfo.fail(fmt.Sprintf("CreateStream[%d]", i), st)
+				return
+			}
 		}
+	})
+	<-done
+	return copyErr
+}
+
+// initDomain makes the NTT domain of the device the one of d0. The domain is
+// global to the device, so the caller must hold its lock.
+func (di *deviceInfo) initDomain(lock *deviceLock, d0 *fft.Domain) error {
+	if lock.domain == d0.Cardinality {
+		return nil
 	}
+	genBits := d0.Generator.Bits()
+	limbs := icicle_core.ConvertUint64ArrToUint32Arr(genBits[:])
+	var rou icicle_bls12_381.ScalarField
+	rou = rou.FromLimbs(limbs)
 
+	var stRls icicle_runtime.EIcicleError
+	var stInit icicle_runtime.EIcicleError
+	done := make(chan struct{})
+	icicle_runtime.RunOnDevice(&di.Device, func(args ...any) {
+		defer close(done)
+		stRls = icicle_ntt.ReleaseDomain()
+		stInit = icicle_ntt.InitDomain(rou, icicle_core.GetDefaultNTTInitDomainConfig())
+	})
+	<-done
+	if stRls != icicle_runtime.Success {
+		lock.domain = 0
+		return di.fail("ReleaseDomain", stRls)
+	}
+	if stInit != icicle_runtime.Success {
+		lock.domain = 0
+		return di.fail("InitDomain", stInit)
+	}
+	lock.domain = d0.Cardinality
 	return nil
-
 }
 
 func hostFromFrSlice(v []fr.Element) icicle_core.HostSlice[fr.Element] {
 	return icicle_core.HostSliceFromElements(v)
 }
 
-func prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
-	var setupDeviceDur time.Duration
+func prove(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (proof *plonkbls12381.Proof, err error) {
+	// pk may be shared by concurrent calls on other devices
+	local := *pk
+	pk = &local
+	pk.obs = observer.Start(ctx, pk.Observer, observer.BACKEND_ICICLE, spr.GetNbConstraints())
+	defer func() { pk.obs.End(err) }()
+	o := pk.obs
+
+	// the setup round ends with newInstance, or with the proof when it fails first
+	o.RoundStart(observer.ROUND_SETUP)
+	defer func() { o.RoundEnd(observer.ROUND_SETUP, err) }()
+	device, release, err := pk.device(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	lock, err := lockDevice(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
 	if HasIcicle {
-		t0 := time.Now()
-		if err := pk.setupDevicePointers(spr); err != nil {
+		info, err := pk.acquireDeviceInfo(spr, device)
+		if err != nil {
 			return nil, fmt.Errorf("icicle device setup: %w", err)
 		}
-		setupDeviceDur = time.Since(t0)
+		defer info.release()
+		if err := info.initDomain(lock, fft.NewDomain(uint64(info.N), fft.WithoutPrecompute())); err != nil {
+			return nil, fmt.Errorf("icicle device setup: %w", err)
+		}
+		pk.deviceInfo = info
 	}
 
-	// log := logger.Logger().With().
-	// 	Str("curve", spr.CurveID().String()).
-	// 	Int("nbConstraints", spr.GetNbConstraints()).
-	// 	Str("backend", "plonk").Logger()
-
 	// parse the options
 	opt, err := backend.NewProverConfig(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("get prover options: %w", err)
 	}
 
-	// start := time.Now()
-
 	// init instance
-	tSetup := time.Now()
-	g, ctx := errgroup.WithContext(context.Background())
-	instance, err := newInstance(ctx, spr, pk, fullWitness, &opt)
+	g, gctx := errgroup.WithContext(ctx)
+	instance, err := newInstance(gctx, spr, pk, fullWitness, &opt)
+	o.RoundEnd(observer.ROUND_SETUP, err)
 	if err != nil {
 		return nil, fmt.Errorf("new instance: %w", err)
 	}
-	setupInstanceDur := time.Since(tSetup)
-
-	tProve := time.Now()
 
 	// solve constraints
-	g.Go(instance.solveConstraints)
+	g.Go(o.Round(observer.ROUND_SOLVE, instance.solveConstraints))
 
 	// complete qk
 	g.Go(instance.completeQk)
@@ -395,29 +427,35 @@ func prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts
 	g.Go(instance.initBlindingPolynomials)
 
 	// derive gamma, beta (copy constraint)
-	g.Go(instance.deriveGammaAndBeta)
+	g.Go(o.Round(observer.ROUND_GAMMA_BETA, instance.deriveGammaAndBeta))
 
 	// compute accumulating ratio for the copy constraint
-	g.Go(instance.buildRatioCopyConstraint)
+	g.Go(o.Round(observer.ROUND_Z, instance.buildRatioCopyConstraint))
 
 	// compute h
-	g.Go(instance.computeQuotient)
+	g.Go(o.Round(observer.ROUND_QUOTIENT, instance.computeQuotient))
 
 	// open Z (blinded) at ωζ (proof.ZShiftedOpening)
 	g.Go(instance.openZ)
 
 	// linearized polynomial
-	g.Go(instance.computeLinearizedPolynomial)
+	g.Go(o.Round(observer.ROUND_LINEARIZATION, instance.computeLinearizedPolynomial))
 
 	// Batch opening
-	g.Go(instance.batchOpening)
+	g.Go(o.Round(observer.ROUND_OPENING, instance.batchOpening))
 
-	if err := g.Wait(); err != nil {
+	err = g.Wait()
+	// device buffers of the background steps must be freed before the device is released
+	instance.background.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := instance.deviceError(); err != nil {
 		return nil, err
 	}
-	proveDur := time.Since(tProve)
-
-	log.Printf("plonk prover timing: setup_device=%s, setup_instance=%s, prove=%s", setupDeviceDur, setupInstanceDur, proveDur)
 	return instance.proof, nil
 }
 
@@ -430,7 +468,7 @@ type instance struct {
 	spr   *cs.SparseR1CS
 	opt   *backend.ProverConfig
 
-	fs *Transcript
+	fs *eon.Transcript
 
 	// polynomials
 	x                         []*iop.Polynomial // x stores tracks the polynomial we need
@@ -467,6 +505,27 @@ type instance struct {
 	domain0, domain1 *fft.Domain
 
 	trace *plonkbls12381.Trace
+
+	// first failed device step, the proof is then redone on CPU by the caller
+	deviceErr   error
+	deviceErrMu sync.Mutex
+
+	// steps running outside the errgroup
+	background sync.WaitGroup
+}
+
+func (s *instance) failDevice(err error) {
+	s.deviceErrMu.Lock()
+	defer s.deviceErrMu.Unlock()
+	if s.deviceErr == nil {
+		s.deviceErr = err
+	}
+}
+
+func (s *instance) deviceError() error {
+	s.deviceErrMu.Lock()
+	defer s.deviceErrMu.Unlock()
+	return s.deviceErr
 }
 
 func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts *backend.ProverConfig) (*instance, error) {
@@ -478,7 +537,7 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		opt:                    opts,
 		fullWitness:            fullWitness,
 		bp:                     make([]*iop.Polynomial, nb_blinding_polynomials),
-		fs:                     NewTranscript(eon.CID_GAMMA, eon.CID_BETA, eon.CID_ALPHA, eon.CID_ZETA),
+		fs:                     eon.NewTranscript(eon.POSEIDON2, eon.CID_GAMMA, eon.CID_BETA, eon.CID_ALPHA, eon.CID_ZETA),
 		chLRO:                  make(chan struct{}, 1),
 		chQk:                   make(chan struct{}, 1),
 		chbp:                   make(chan struct{}, 1),
@@ -512,8 +571,12 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		s.domain1 = fft.NewDomain(4*sizeSystem, fft.WithoutPrecompute())
 	}
 
-	// build trace
-	s.trace = plonkbls12381.NewTrace(spr, s.domain0)
+	// build trace, or reuse a precomputed one; the prover mutates it in place
+	if pk.Trace != nil && uint64(pk.Trace.Ql.Size()) == s.domain0.Cardinality {
+		s.trace = CloneTrace(pk.Trace)
+	} else {
+		s.trace = plonkbls12381.NewTrace(spr, s.domain0)
+	}
 
 	return &s, nil
 }
@@ -576,6 +639,7 @@ func (s *instance) bsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
 // solveConstraints computes the evaluation of the polynomials L, R, O
 // and sets x[id_L], x[id_R], x[id_O] in Lagrange form
 func (s *instance) solveConstraints() error {
+	s.pk.obs.RoundStart(observer.ROUND_SOLVE)
 	_solution, err := s.spr.Solve(s.fullWitness, s.opt.SolverOpts...)
 	if err != nil {
 		return err
@@ -598,9 +662,10 @@ func (s *instance) solveConstraints() error {
 	s.x[id_O] = iop.NewPolynomial(&evaluationODomainSmall, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
 
 	wg.Wait()
+	s.pk.obs.RoundEnd(observer.ROUND_SOLVE, nil)
 
 	// commit to l, r, o and add blinding factors
-	if err := s.commitToLRO(); err != nil {
+	if err := s.pk.obs.Round(observer.ROUND_LRO, s.commitToLRO)(); err != nil {
 		return err
 	}
 	close(s.chLRO)
@@ -672,29 +737,40 @@ func (s *instance) commitToLRO() error {
 		return errContextDone
 	case <-s.chbp:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.pk.obs.RoundStart(observer.ROUND_LRO)
 
 	var streamL, streamR, streamO icicle_runtime.Stream
 
 	if HasIcicle && s.pk != nil && s.pk.deviceInfo != nil {
-		var st icicle_runtime.EIcicleError
-
-		streamL, st = icicle_runtime.CreateStream()
-		if st != icicle_runtime.Success {
-			return fmt.Errorf("create streamL: %s", st.AsString())
-		}
-		defer icicle_runtime.DestroyStream(streamL)
-
-		streamR, st = icicle_runtime.CreateStream()
-		if st != icicle_runtime.Success {
-			return fmt.Errorf("create streamR: %s", st.AsString())
+		var streamErr error
+		done := make(chan struct{})
+		icicle_runtime.RunOnDevice(&s.pk.deviceInfo.Device, func(args ...any) {
+			defer close(done)
+			var st icicle_runtime.EIcicleError
+			if streamL, st = icicle_runtime.CreateStream(); st != icicle_runtime.Success {
+				streamErr = s.pk.deviceInfo.fail("create streamL", st)
+				return
+			}
+			if streamR, st = icicle_runtime.CreateStream(); st != icicle_runtime.Success {
+				streamErr = s.pk.deviceInfo.fail("create streamR", st)
+				return
+			}
+			if streamO, st = icicle_runtime.CreateStream(); st != icicle_runtime.Success {
+				streamErr = s.pk.deviceInfo.fail("create streamO", st)
+			}
+		})
+		<-done
+		for _, stream := range []icicle_runtime.Stream{streamL, streamR, streamO} {
+			if stream != nil {
+				defer icicle_runtime.DestroyStream(stream)
+			}
 		}
-		defer icicle_runtime.DestroyStream(streamR)
-
-		streamO, st = icicle_runtime.CreateStream()
-		if st != icicle_runtime.Success {
-			return fmt.Errorf("create streamO: %s", st.AsString())
+		if streamErr != nil {
+			return streamErr
 		}
-		defer icicle_runtime.DestroyStream(streamO)
 	}
 
 	// using errgroup to commit L, R, O in parallel
@@ -749,7 +825,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return witness.ErrInvalidWitness
 	}
 
-	if err := Dev_bindPublicData(s.fs, eon.CID_GAMMA, s.pk.Vk, wWitness[:len(s.spr.Public)]); err != nil {
+	if err := eon.BindVerifyingKey(s.fs, eon.CID_GAMMA, s.pk.Vk); err != nil {
 		return err
 	}
 
@@ -759,6 +835,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return errContextDone
 	case <-s.chLRO:
 	}
+	s.pk.obs.RoundStart(observer.ROUND_GAMMA_BETA)
 
 	if err := s.fs.Bind(eon.CID_GAMMA, eon.HashG1(s.proof.LRO[0])); err != nil {
 		return err
@@ -773,7 +850,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return err
 	}
 
-	gamma, err := Dev_deriveRandomness(s.fs, eon.CID_GAMMA)
+	gamma, err := eon.DeriveRandomness(s.fs, eon.CID_GAMMA)
 	if err != nil {
 		return err
 	}
@@ -819,6 +896,7 @@ func (s *instance) commitToPolyAndBlindingStream(
 ) (commit curve.G1Affine, err error) {
 
 	coeffs := p.Coefficients()
+	s.pk.observeMSM(len(coeffs))
 
 	if HasIcicle && s.pk != nil && s.pk.deviceInfo != nil {
 		// GPU path
@@ -844,15 +922,10 @@ func (s *instance) commitToPolyAndBlindingStream(
 		})
 		<-done
 
-		if st == icicle_runtime.Success {
-			commit = curve.G1Affine(dig)
-		} else {
-			log.Printf("[GPU failed -> CPU] commitToPolyAndBlindingStream main poly: %s", st.AsString())
-			commit, err = kzg.Commit(coeffs, s.pk.KzgLagrange)
-			if err != nil {
-				return curve.G1Affine{}, err
-			}
+		if st != icicle_runtime.Success {
+			return curve.G1Affine{}, s.pk.deviceInfo.fail("commit", st)
 		}
+		commit = curve.G1Affine(dig)
 	} else {
 		// CPU path
 		commit, err = kzg.Commit(coeffs, s.pk.KzgLagrange)
@@ -885,12 +958,12 @@ func (s *instance) deriveAlpha() (err error) {
 		alphaDeps[i] = &s.proof.Bsb22Commitments[i]
 	}
 	alphaDeps[len(alphaDeps)-1] = &s.proof.Z
-	s.alpha, err = Dev_deriveRandomness(s.fs, eon.CID_ALPHA, alphaDeps...)
+	s.alpha, err = eon.DeriveRandomness(s.fs, eon.CID_ALPHA, alphaDeps...)
 	return err
 }
 
 func (s *instance) deriveZeta() (err error) {
-	s.zeta, err = Dev_deriveRandomness(s.fs, eon.CID_ZETA, &s.proof.H[0], &s.proof.H[1], &s.proof.H[2])
+	s.zeta, err = eon.DeriveRandomness(s.fs, eon.CID_ZETA, &s.proof.H[0], &s.proof.H[1], &s.proof.H[2])
 	return
 }
 
@@ -929,6 +1002,10 @@ func (s *instance) computeQuotient() (err error) {
 		return errContextDone
 	case <-s.chZ:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.pk.obs.RoundStart(observer.ROUND_QUOTIENT)
 
 	// derive alpha
 	if err = s.deriveAlpha(); err != nil {
@@ -950,6 +1027,7 @@ func (s *instance) computeQuotient() (err error) {
 	if err != nil {
 		return err
 	}
+	s.pk.obs.NTT(observer.BACKEND_CPU, int(s.domain1.Cardinality))
 
 	// commit to h
 	// if err := commitToQuotient(s.h1(), s.h2(), s.h3(), s.proof, s.pk.Kzg); err != nil {
@@ -980,6 +1058,10 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 		return errContextDone
 	case <-s.chGammaBeta:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.pk.obs.RoundStart(observer.ROUND_Z)
 
 	// TODO @gbotrel having iop.BuildRatioCopyConstraint return something
 	// with capacity = len() + 4 would avoid extra alloc / copy during openZ
@@ -1015,6 +1097,9 @@ func (s *instance) openZ() (err error) {
 		return errContextDone
 	case <-s.chH:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
 	var zetaShifted fr.Element
 	zetaShifted.Mul(&s.zeta, &s.pk.Vk.Generator)
 	s.blindedZ = getBlindedCoefficients(s.x[id_Z], s.bp[id_Bz])
@@ -1074,6 +1159,10 @@ func (s *instance) computeLinearizedPolynomial() error {
 		return errContextDone
 	case <-s.chH:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.pk.obs.RoundStart(observer.ROUND_LINEARIZATION)
 
 	qcpzeta := make([]fr.Element, len(s.commitmentInfo))
 	var blzeta, brzeta, bozeta fr.Element
@@ -1146,6 +1235,10 @@ func (s *instance) batchOpening() error {
 		return errContextDone
 	case <-s.chLinearizedPolynomial:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.pk.obs.RoundStart(observer.ROUND_OPENING)
 
 	polysQcp := coefficients(s.trace.Qcp)
 	polysToOpen := make([][]fr.Element, 6+len(polysQcp))
@@ -1344,6 +1437,18 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 		var upErr error
 		doneUpload := make(chan struct{})
+		defer func() {
+			doneFree := make(chan struct{})
+			icicle_runtime.RunOnDevice(&s.pk.deviceInfo.Device, func(args ...any) {
+				defer close(doneFree)
+				for i := range devX {
+					if devX[i].AsUnsafePointer() != nil {
+						devX[i].Free()
+					}
+				}
+			})
+			<-doneFree
+		}()
 
 		icicle_runtime.RunOnDevice(&s.pk.deviceInfo.Device, func(args ...any) {
 			defer close(doneUpload)
@@ -1354,12 +1459,17 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 				host := icicle_core.HostSliceFromElements(s.x[i].Coefficients())
 				host.CopyToDevice(&devX[i], true)
+				s.pk.obs.Transfer(true, len(host)*fr.Bytes)
 
 				if s.x[i].Basis != iop.Canonical {
+					if upErr = s.pk.deviceInfo.checkDomain("INttOnDevice", devX[i].Len()); upErr != nil {
+						return
+					}
 					if st := kzg_bls12_381.INttOnDevice(devX[i]); st != icicle_runtime.Success {
-						upErr = fmt.Errorf("INttOnDevice poly[%d]: %s", i, st.AsString())
+						upErr = s.pk.deviceInfo.fail(fmt.Sprintf("INttOnDevice poly[%d]", i), st)
 						return
 					}
+					s.pk.obs.NTT(observer.BACKEND_ICICLE, devX[i].Len())
 				}
 				uploadedIdx = append(uploadedIdx, i)
 				poly2idx[s.x[i]] = i
@@ -1367,13 +1477,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 		})
 		<-doneUpload
 		if upErr != nil {
-			useGPU = false
-			icicle_runtime.RunOnDevice(&s.pk.deviceInfo.Device, func(args ...any) {
-				for _, idx := range uploadedIdx {
-					devX[idx].Free()
-
-				}
-			})
+			return nil, upErr
 		}
 	}
 
@@ -1381,6 +1485,9 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 	bufBatchInvert := make([]fr.Element, s.domain0.Cardinality)
 
 	for i := 0; i < rho; i++ {
+		if s.ctx.Err() != nil {
+			return nil, errContextDone
+		}
 		coset.Mul(&coset, &shifters[i]) // i=0: s; i=1: s·w; i=2: s·w²; ...
 		cosetExponentiatedToNMinusOne.Exp(coset, bn).
 			Sub(&cosetExponentiatedToNMinusOne, &one)
@@ -1428,12 +1535,20 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 				if idx, ok := poly2idx[p]; ok {
 					// idx % numStreams to distribute load
 					stream := streams[idx%numStreams]
-					_ = s.toCosetLagrangeOnGPUorCPU_DEV(p, wDevReg, wDevRev, sk, &devX[idx], stream)
+					if err := s.toCosetLagrangeOnGPUorCPU_DEV(p, wDevReg, wDevRev, sk, &devX[idx], stream); err != nil {
+						s.failDevice(err)
+					}
 					return
 				}
 			}
-			_ = s.toCosetLagrangeOnGPUorCPU_DEV(p, wDevReg, wDevRev, sk, nil, nil)
+			if err := s.toCosetLagrangeOnGPUorCPU_DEV(p, wDevReg, wDevRev, sk, nil, nil); err != nil {
+				s.failDevice(err)
+			}
 		})
+		if err := s.deviceError(); err != nil {
+			wgBuf.Wait()
+			return nil, err
+		}
 
 		wgBuf.Wait()
 
@@ -1465,7 +1580,11 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 		}
 	}
 
-	go s.scaleEverythingBackGPUorCPU(shifters, poly2idx, devX)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.scaleEverythingBackGPUorCPU(shifters, poly2idx, devX)
+	}()
 
 	// ensure all the goroutines are done
 	wgBuf.Wait()
@@ -1771,6 +1890,7 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 	s3canonical := s.trace.S3.Coefficients()
 
 	s.trace.Qk.ToCanonical(s.domain0).ToRegular()
+	s.pk.obs.NTT(observer.BACKEND_CPU, int(s.domain0.Cardinality))
 
 	// len(h1)=len(h2)=len(blindedZCanonical)=len(h3)+1 when Statistical ZK is activated
 	// len(h1)=len(h2)=len(h3)=len(blindedZCanonical)-1 when Statistical ZK is deactivated
@@ -2001,7 +2121,7 @@ func dividePolyByXminusA(f []fr.Element, fa, a fr.Element) []fr.Element {
 func deriveGamma(point fr.Element, digests []kzg.Digest, claimedValues []fr.Element, dataTranscript ...fr.Element) (fr.Element, error) {
 
 	// derive the challenge gamma, binded to the point and the commitments
-	fs := NewTranscript(eon.CID_GAMMA)
+	fs := eon.NewTranscript(eon.POSEIDON2, eon.CID_GAMMA)
 	if err := fs.Bind(eon.CID_GAMMA, point); err != nil {
 		return fr.Element{}, err
 	}
@@ -2054,135 +2174,6 @@ func fold(di []kzg.Digest, fai []fr.Element, ci []fr.Element) (kzg.Digest, fr.El
 
 }
 
-var (
-	errChallengeNotFound            = errors.New("challenge not recorded in the transcript")
-	errChallengeAlreadyComputed     = errors.New("challenge already computed, cannot be binded to other values")
-	errPreviousChallengeNotComputed = errors.New("the previous challenge is needed and has not been computed")
-)
-
-// Transcript handles the creation of challenges for Fiat Shamir.
-type Transcript struct {
-	challenges map[fr.Element]challenge
-	previous   *challenge
-}
-
-type challenge struct {
-	position   int            // position of the challenge in the Transcript. order matters.
-	bindings   [][]fr.Element // bindings stores the variables a challenge is binded to.
-	value      fr.Element     // value stores the computed challenge
-	isComputed bool
-}
-
-// NewTranscript returns a new transcript.
-// h is the hash function that is used to compute the challenges.
-// challenges are the name of the challenges. The order of the challenges IDs matters.
-func NewTranscript(challengesID ...fr.Element) *Transcript {
-	challenges := make(map[fr.Element]challenge)
-	for i := range challengesID {
-		challenges[challengesID[i]] = challenge{position: i}
-	}
-	t := &Transcript{
-		challenges: challenges,
-	}
-	return t
-}
-
-// Bind binds the challenge to value. A challenge can be binded to an
-// arbitrary number of values, but the order in which the binded values
-// are added is important. Once a challenge is computed, it cannot be
-// binded to other values.
-func (t *Transcript) Bind(challengeID fr.Element, bValue ...fr.Element) error {
-
-	currentChallenge, ok := t.challenges[challengeID]
-	if !ok {
-		return errChallengeNotFound
-	}
-
-	if currentChallenge.isComputed {
-		return errChallengeAlreadyComputed
-	}
-
-	bCopy := make([]fr.Element, len(bValue))
-	copy(bCopy, bValue)
-	currentChallenge.bindings = append(currentChallenge.bindings, bCopy)
-	t.challenges[challengeID] = currentChallenge
-
-	return nil
-
-}
-
-// ComputeChallenge computes the challenge corresponding to the given name.
-// The challenge is:
-// * H(name || previous_challenge || binded_values...) if the challenge is not the first one
-// * H(name || binded_values... ) if it is the first challenge
-func (t *Transcript) ComputeChallenge(challengeID fr.Element) (fr.Element, error) {
-	challenge, ok := t.challenges[challengeID]
-	if !ok {
-		return fr.Element{}, errChallengeNotFound
-	}
-
-	// if the challenge was already computed we return it
-	if challenge.isComputed {
-		return challenge.value, nil
-	}
-
-	// reset before populating the internal state
-	resfrom := []fr.Element{}
-
-	resfrom = append(resfrom, challengeID)
-
-	// write the previous challenge if it's not the first challenge
-	if challenge.position != 0 {
-		if t.previous == nil || (t.previous.position != challenge.position-1) {
-			return fr.Element{}, errPreviousChallengeNotComputed
-		}
-		resfrom = append(resfrom, t.previous.value)
-	}
-
-	// write the binded values in the order they were added
-	for _, b := range challenge.bindings {
-		resfrom = append(resfrom, b...)
-	}
-
-	// compute the hash of the accumulated values
-	res := hashsum(resfrom...)
-
-	challenge.value = res
-	challenge.isComputed = true
-
-	t.challenges[challengeID] = challenge
-	t.previous = &challenge
-
-	return res, nil
-
-}
-
-const WIDTH = 2
-const ROuND_FULL = 8
-const ROUND_PARTIAL = 56
-
-var GetPermutation = sync.OnceValue(func() *poseidon2.Permutation {
-	return poseidon2.NewPermutationWithSeed(WIDTH, ROuND_FULL, ROUND_PARTIAL, "EON_POSEIDON2_HASH_SEED")
-})
-
-func compress(x, y fr.Element) fr.Element {
-	vars := [2]fr.Element{x, y}
-	if err := GetPermutation().Permutation(vars[:]); err != nil {
-		log.Fatalln(err)
-	}
-	var ret fr.Element
-	ret.Add(&vars[1], &y)
-	return ret
-}
-
-func hashsum(val ...fr.Element) fr.Element {
-	var ret fr.Element
-	for _, v := range val {
-		ret = compress(ret, v)
-	}
-	return ret
-}
-
 func parallelize(nbIterations int, work func(int, int), maxCpus ...int) {
 
 	nbTasks := runtime.NumCPU()
@@ -2219,59 +2210,9 @@ func parallelize(nbIterations int, work func(int, int), maxCpus ...int) {
 
 	wg.Wait()
 }
-func Dev_bindPublicData(fs *Transcript, challenge fr.Element, vk *plonkbls12381.VerifyingKey, publicInputs []fr.Element) error {
-
-	// permutation
-	if err := fs.Bind(challenge, eon.HashG1(vk.S[0])); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.S[1])); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.S[2])); err != nil {
-		return err
-	}
-
-	// coefficients
-	if err := fs.Bind(challenge, eon.HashG1(vk.Ql)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.Qr)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.Qm)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.Qo)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, eon.HashG1(vk.Qk)); err != nil {
-		return err
-	}
-	for i := range vk.Qcp {
-		if err := fs.Bind(challenge, eon.HashG1(vk.Qcp[i])); err != nil {
-			return err
-		}
-	}
-
-	return nil
-
-}
-
-func Dev_deriveRandomness(fs *Transcript, challenge fr.Element, points ...*curve.G1Affine) (fr.Element, error) {
-	for _, p := range points {
-		if err := fs.Bind(challenge, eon.HashG1(*p)); err != nil {
-			return fr.Element{}, err
-		}
-	}
-	b, err := fs.ComputeChallenge(challenge)
-	if err != nil {
-		return fr.Element{}, err
-	}
-	return b, nil
-}
 
 func commitOnGPUOrCPU(coeffs []fr.Element, pk *ProvingKey, useLagrange bool) (curve.G1Affine, error) {
+	pk.observeMSM(len(coeffs))
 	// GPU
 	if HasIcicle && pk != nil && pk.deviceInfo != nil {
 		var dig kzg.Digest
@@ -2306,10 +2247,10 @@ func commitOnGPUOrCPU(coeffs []fr.Element, pk *ProvingKey, useLagrange bool) (cu
 		})
 		<-done
 
-		if st == icicle_runtime.Success {
-			return curve.G1Affine(dig), nil
+		if st != icicle_runtime.Success {
+			return curve.G1Affine{}, pk.deviceInfo.fail("kzg.Commit", st)
 		}
-		log.Printf("[GPU failed -> CPU] kzg.Commit")
+		return curve.G1Affine(dig), nil
 	}
 
 	// CPU
@@ -2320,7 +2261,7 @@ func commitOnGPUOrCPU(coeffs []fr.Element, pk *ProvingKey, useLagrange bool) (cu
 }
 
 // commits to a polynomial of the form b*(Xⁿ-1) where b is of small degree
-// Prefer GPU (icicle v3) with precomputation; fallback to CPU if GPU unavailable or returns error.
+// Prefer GPU (icicle v3) with precomputation, CPU when no device is set up.
 func commitBlindingFactorGPUOrCPU(n int, b *iop.Polynomial, pk *ProvingKey) (curve.G1Affine, error) {
 	cp := b.Coefficients()
 	np := b.Size()
@@ -2373,22 +2314,24 @@ func commitBlindingFactorGPUOrCPU(n int, b *iop.Polynomial, pk *ProvingKey) (cur
 		})
 		<-done
 
-		if stLo == icicle_runtime.Success && stHi == icicle_runtime.Success {
-			res := curve.G1Affine(hi)
-			tmp := curve.G1Affine(lo)
-			res.Sub(&res, &tmp)
-			return res, nil
+		if stLo != icicle_runtime.Success {
+			return curve.G1Affine{}, pk.deviceInfo.fail("commit blinding factor lo", stLo)
 		}
-
-		log.Printf("[GPU failed -> CPU] commit blinding factor (lo=%s hi=%s)", stLo.AsString(), stHi.AsString())
+		if stHi != icicle_runtime.Success {
+			return curve.G1Affine{}, pk.deviceInfo.fail("commit blinding factor hi", stHi)
+		}
+		res := curve.G1Affine(hi)
+		tmp := curve.G1Affine(lo)
+		res.Sub(&res, &tmp)
+		return res, nil
 	}
 
-	// --- CPU fallback ---
+	// --- CPU path ---
 	return commitBlindingFactor(n, b, pk.Kzg), nil
 }
 
 // commits to a polynomial of the form b*(Xⁿ-1) where b is of small degree
-// Prefer GPU (icicle v3); fallback to CPU if GPU unavailable or returns error.
+// Prefer GPU (icicle v3), CPU when no device is set up.
 func commitBlindingFactorGPUOrCPUWithStream(n int, b *iop.Polynomial, pk *ProvingKey, stream icicle_runtime.Stream) (curve.G1Affine, error) {
 	cp := b.Coefficients()
 	np := b.Size()
@@ -2443,21 +2386,24 @@ func commitBlindingFactorGPUOrCPUWithStream(n int, b *iop.Polynomial, pk *Provin
 		})
 		<-done
 
-		if stLo == icicle_runtime.Success && stHi == icicle_runtime.Success {
-			res := curve.G1Affine(hi)
-			tmp := curve.G1Affine(lo)
-			res.Sub(&res, &tmp)
-			return res, nil
+		if stLo != icicle_runtime.Success {
+			return curve.G1Affine{}, pk.deviceInfo.fail("commit blinding factor lo", stLo)
 		}
-
-		log.Printf("[GPU failed -> CPU] commit blinding factor (lo=%s hi=%s)", stLo.AsString(), stHi.AsString())
+		if stHi != icicle_runtime.Success {
+			return curve.G1Affine{}, pk.deviceInfo.fail("commit blinding factor hi", stHi)
+		}
+		res := curve.G1Affine(hi)
+		tmp := curve.G1Affine(lo)
+		res.Sub(&res, &tmp)
+		return res, nil
 	}
 
-	// --- CPU fallback ---
+	// --- CPU path ---
 	return commitBlindingFactor(n, b, pk.Kzg), nil
 }
 
 func OpenOnGPUOrCPU(p []fr.Element, point fr.Element, pk *ProvingKey) (kzg.OpeningProof, error) {
+	pk.observeMSM(len(p))
 	if HasIcicle && pk != nil && pk.deviceInfo != nil {
 		var pr kzg.OpeningProof
 		var st icicle_runtime.EIcicleError
@@ -2481,10 +2427,10 @@ func OpenOnGPUOrCPU(p []fr.Element, point fr.Element, pk *ProvingKey) (kzg.Openi
 		})
 		<-done
 
-		if st == icicle_runtime.Success {
-			return pr, nil
+		if st != icicle_runtime.Success {
+			return kzg.OpeningProof{}, pk.deviceInfo.fail("kzg.Open", st)
 		}
-		log.Printf("[GPU failed -> CPU] kzg.Open: %s", st.AsString())
+		return pr, nil
 	}
 	return kzg.Open(p, point, pk.Kzg)
 }
@@ -2517,38 +2463,44 @@ func (s *instance) toCosetLagrangeOnGPUorCPU_DEV(
 			dev := *xdev
 
 			if st = kzg_bls12_381.MontConvOnDevice(dev, false); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("MontConv(dev->nonMont) failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("MontConv(dev->nonMont)", st)
 				return
 			}
 			if st = kzg_bls12_381.VecMulOnDeviceStream(dev, selW, stream); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("VecMulOnDevice failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("VecMulOnDevice", st)
 				return
 			}
 			if st = kzg_bls12_381.MontConvOnDevice(dev, true); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("MontConv(dev->Mont) failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("MontConv(dev->Mont)", st)
 				return
 			}
 
+			if gpuErr = s.pk.deviceInfo.checkDomain("NttOnDevice", dev.Len()); gpuErr != nil {
+				return
+			}
 			if st = kzg_bls12_381.NttOnDeviceStream(dev, stream); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("NttOnDevice failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("NttOnDevice", st)
 				return
 			}
 
 			host := icicle_core.HostSliceFromElements(coeffs)
 			host.CopyFromDevice(&dev)
+			s.pk.obs.Transfer(false, len(host)*fr.Bytes)
 
 			if st = kzg_bls12_381.INttOnDeviceStream(dev, stream); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("INttOnDevice (restore canonical) failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("INttOnDevice (restore canonical)", st)
 				return
 			}
+			s.pk.obs.NTT(observer.BACKEND_ICICLE, dev.Len())
+			s.pk.obs.NTT(observer.BACKEND_ICICLE, dev.Len())
 		})
 		<-done
 
-		if gpuErr == nil {
-			*p = *iop.NewPolynomial(&coeffs, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
-			return nil
+		if gpuErr != nil {
+			return gpuErr
 		}
-		log.Printf("[GPU failed -> CPU] %v", gpuErr)
+		*p = *iop.NewPolynomial(&coeffs, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
+		return nil
 	}
 
 	nbTasks := calculateNbTasks(len(s.x)-1) * 2
@@ -2582,6 +2534,8 @@ func (s *instance) toCosetLagrangeOnGPUorCPU_DEV(
 	}, nbTasks)
 
 	p.ToLagrange(s.domain0, nbTasks).ToRegular()
+	s.pk.obs.NTT(observer.BACKEND_CPU, len(cp))
+	s.pk.obs.NTT(observer.BACKEND_CPU, len(cp))
 	return nil
 }
 
@@ -2635,10 +2589,11 @@ func (s *instance) scaleEverythingBackGPUorCPU(
 			hostW := icicle_core.HostSliceFromElements(accList)
 			var wDevFull icicle_core.DeviceSlice
 			hostW.CopyToDevice(&wDevFull, true)
+			s.pk.obs.Transfer(true, len(hostW)*fr.Bytes)
 			defer wDevFull.Free()
 
 			if st := kzg_bls12_381.MontConvOnDevice(wDevFull, false /* FromMontgomery */); st != icicle_runtime.Success {
-				gpuErr = fmt.Errorf("FromMontgomery(accList) failed: %s", st.AsString())
+				gpuErr = s.pk.deviceInfo.fail("FromMontgomery(accList)", st)
 				return
 			}
 
@@ -2664,45 +2619,51 @@ func (s *instance) scaleEverythingBackGPUorCPU(
 				hostP := icicle_core.HostSliceFromElements(coeffs)
 				var dev icicle_core.DeviceSlice
 				hostP.CopyToDevice(&dev, true)
+				s.pk.obs.Transfer(true, deg*fr.Bytes)
 
+				if gpuErr = s.pk.deviceInfo.checkDomain("INttOnDevice", deg); gpuErr != nil {
+					dev.Free()
+					return
+				}
 				if st := kzg_bls12_381.INttOnDevice(dev); st != icicle_runtime.Success {
-					gpuErr = fmt.Errorf("poly[%d] INTT failed: %s", idx, st.AsString())
+					gpuErr = s.pk.deviceInfo.fail(fmt.Sprintf("poly[%d] INTT", idx), st)
 					dev.Free()
 					return
 				}
+				s.pk.obs.NTT(observer.BACKEND_ICICLE, deg)
 				p.Form.Basis = iop.Canonical
 				p.Form.Layout = iop.Regular
 
 				if st := kzg_bls12_381.MontConvOnDevice(dev, false /* FromMontgomery */); st != icicle_runtime.Success {
-					gpuErr = fmt.Errorf("poly[%d] FromMontgomery: %s", idx, st.AsString())
+					gpuErr = s.pk.deviceInfo.fail(fmt.Sprintf("poly[%d] FromMontgomery", idx), st)
 					dev.Free()
 					return
 				}
 
 				if st := kzg_bls12_381.VecMulOnDevice(dev, wDev); st != icicle_runtime.Success {
-					gpuErr = fmt.Errorf("poly[%d] VecMul: %s", idx, st.AsString())
+					gpuErr = s.pk.deviceInfo.fail(fmt.Sprintf("poly[%d] VecMul", idx), st)
 					dev.Free()
 					return
 				}
 				if st := kzg_bls12_381.MontConvOnDevice(dev, true /* ToMontgomery */); st != icicle_runtime.Success {
-					gpuErr = fmt.Errorf("poly[%d] ToMontgomery: %s", idx, st.AsString())
+					gpuErr = s.pk.deviceInfo.fail(fmt.Sprintf("poly[%d] ToMontgomery", idx), st)
 					dev.Free()
 					return
 				}
 
 				hostP.CopyFromDevice(&dev)
+				s.pk.obs.Transfer(false, deg*fr.Bytes)
 				dev.Free()
 			}
 		})
 		<-done
 
 		if gpuErr != nil {
-			log.Printf("[GPU failed -> CPU] scaleEverythingBack: %v", gpuErr)
-			useGPU = false
+			s.failDevice(gpuErr)
 		}
 	}
 
-	// CPU fallback
+	// CPU path
 	if !useGPU {
 		batchApply(s.x, func(p *iop.Polynomial) {
 			if p == nil {
@@ -2710,6 +2671,7 @@ func (s *instance) scaleEverythingBackGPUorCPU(
 			}
 			p.ToCanonical(s.domain0, 8).ToRegular()
 			scalePowers(p, cs)
+			s.pk.obs.NTT(observer.BACKEND_CPU, n)
 		})
 	} else {
 		// GPU succeeded; nothing to do here.
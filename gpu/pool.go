@@ -0,0 +1,133 @@
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Device names an ICICLE device, e.g. {"CUDA", 1} or {"CPU", 0}.
+type Device struct {
+	Type string
+	Id   int
+}
+
+var DEFAULT_DEVICE = Device{Type: "CUDA", Id: 0}
+
+func (me Device) String() string {
+	return fmt.Sprintf("%s:%d", me.Type, me.Id)
+}
+
+// ParseDevice parses "TYPE:ID" or "TYPE", the latter meaning id 0.
+func ParseDevice(s string) (Device, error) {
+	kind, id, found := strings.Cut(s, ":")
+	if kind == "" {
+		return Device{}, fmt.Errorf("invalid device %q", s)
+	}
+	ret := Device{Type: strings.ToUpper(kind)}
+	if found {
+		var err error
+		if ret.Id, err = strconv.Atoi(id); err != nil || ret.Id < 0 {
+			return Device{}, fmt.Errorf("invalid device id in %q", s)
+		}
+	}
+	return ret, nil
+}
+
+// DevicePool hands each concurrent proof its own device. Every device proves
+// one proof at a time, a proof waits in the queue of the device with the
+// fewest queued proofs.
+type DevicePool struct {
+	mu    sync.Mutex
+	slots []*deviceSlot
+}
+
+type deviceSlot struct {
+	device Device
+	lock   chan struct{}
+	queued int
+}
+
+func NewDevicePool(devices ...Device) (*DevicePool, error) {
+	if len(devices) == 0 {
+		return nil, errors.New("device pool is empty")
+	}
+	ret := DevicePool{}
+	for _, device := range devices {
+		for _, slot := range ret.slots {
+			if slot.device == device {
+				return nil, fmt.Errorf("device %s is given twice", device)
+			}
+		}
+		ret.slots = append(ret.slots, &deviceSlot{device: device, lock: make(chan struct{}, 1)})
+	}
+	return &ret, nil
+}
+
+func (me *DevicePool) Devices() []Device {
+	ret := make([]Device, len(me.slots))
+	for i, slot := range me.slots {
+		ret[i] = slot.device
+	}
+	return ret
+}
+
+// Queued returns the number of running and waiting proofs of every device.
+func (me *DevicePool) Queued() []int {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	ret := make([]int, len(me.slots))
+	for i, slot := range me.slots {
+		ret[i] = slot.queued
+	}
+	return ret
+}
+
+// acquire waits until the least loaded device is free and returns it. The
+// slot must be given back with release.
+func (me *DevicePool) acquire(ctx context.Context) (*deviceSlot, error) {
+	me.mu.Lock()
+	slot := me.slots[0]
+	for _, v := range me.slots[1:] {
+		if v.queued < slot.queued {
+			slot = v
+		}
+	}
+	slot.queued++
+	me.mu.Unlock()
+	select {
+	case slot.lock <- struct{}{}:
+		return slot, nil
+	case <-ctx.Done():
+		me.mu.Lock()
+		slot.queued--
+		me.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (me *DevicePool) release(slot *deviceSlot) {
+	<-slot.lock
+	me.mu.Lock()
+	slot.queued--
+	me.mu.Unlock()
+}
+
+// device returns the device a proof with pk runs on, acquiring it from the
+// pool when there is one.
+func (pk *ProvingKey) device(ctx context.Context) (Device, func(), error) {
+	if pk.Pool != nil {
+		slot, err := pk.Pool.acquire(ctx)
+		if err != nil {
+			return Device{}, nil, err
+		}
+		return slot.device, func() { pk.Pool.release(slot) }, nil
+	}
+	if pk.Device == (Device{}) {
+		return DEFAULT_DEVICE, func() {}, nil
+	}
+	return pk.Device, func() {}, nil
+}
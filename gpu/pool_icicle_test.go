@@ -0,0 +1,162 @@
+//go:build icicle
+
+package gpu_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	cs "github.com/consensys/gnark/constraint/bls12-381"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/gpu"
+	"github.com/eon-protocol/eonark/srs"
+)
+
+type squareCircuit struct {
+	X frontend.Variable `gnark:",public"`
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+	W frontend.Variable `gnark:",public"`
+}
+
+func (me *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(me.X, me.X), me.Y)
+	api.AssertIsEqual(api.Mul(me.Y, me.Y), me.Z)
+	_, err := api.(frontend.Committer).Commit(me.W)
+	return err
+}
+
+// testSRS returns the test SRS for circuits of up to 2^logsize constraints.
+func testSRS(t *testing.T, logsize int) *srs.SRS {
+	s, err := srs.ForTests(logsize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestDevicePoolCPU proves on ICICLE's CPU backend, so it runs without CUDA.
+func TestDevicePoolCPU(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&squareCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+
+	cpu := gpu.Device{Type: "CPU", Id: 0}
+	var report eonark.ProveReport
+	publics, _, proof, err := pk.Prove(&squareCircuit{X: 3, Y: 9, Z: 81, W: 1}, eonark.WithDevice(cpu), eonark.WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+	if report.Prover != eonark.PROVER_ICICLE || report.Fallback != nil {
+		t.Fatalf("got %+v", report)
+	}
+
+	// a device failure redoes the whole proof on CPU
+	publics, _, proof, err = pk.Prove(&squareCircuit{X: 3, Y: 9, Z: 81, W: 1}, eonark.WithDevice(gpu.Device{Type: "NONE", Id: 0}), eonark.WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+	var derr *gpu.DeviceError
+	if report.Prover != eonark.PROVER_CPU || !errors.As(report.Fallback, &derr) || derr.Device.Type != "NONE" {
+		t.Fatalf("got %+v", report)
+	}
+
+	pool, err := gpu.NewDevicePool(cpu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			publics, _, proof, err := pk.Prove(&squareCircuit{X: 3, Y: 9, Z: 81, W: w}, eonark.WithDevicePool(pool))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := vk.Verify(proof, publics); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if q := pool.Queued(); q[0] != 0 {
+		t.Fatalf("queue not drained: %v", q)
+	}
+}
+
+// TestCachedDeviceCPU reuses the device state of a precomputed key until Close.
+func TestCachedDeviceCPU(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&squareCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.Precompute(); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+
+	cpu := eonark.WithDevice(gpu.Device{Type: "CPU", Id: 0})
+	for i := 0; i < 3; i++ {
+		publics, _, proof, err := pk.Prove(&squareCircuit{X: 3, Y: 9, Z: 81, W: i}, cpu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pk.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a closed key sets the device up again on its next proof
+	for i := 0; i < 2; i++ {
+		publics, _, proof, err := pk.Prove(&squareCircuit{X: 3, Y: 9, Z: 81, W: i}, cpu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pk.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a closed wrapped key refuses to prove
+	gpk, err := gpu.WrapProvingKey(pk.ToGnarkProvingKey().(*plonkbls12381.ProvingKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpk.Device = gpu.Device{Type: "CPU", Id: 0}
+	if err := gpk.Close(); err != nil {
+		t.Fatal(err)
+	}
+	witness, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9, Z: 81, W: 1}, eonark.FIELD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs := pk.ToGnarkConstraintSystem().(*cs.SparseR1CS)
+	if _, err := gpu.Prove(ccs, gpk, witness, eonark.OPT_PROVER); err == nil {
+		t.Fatal("expected an error proving with a closed key")
+	}
+}
@@ -0,0 +1,104 @@
+package gpu
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseDevice(t *testing.T) {
+	for s, expected := range map[string]Device{
+		"CUDA:1": {"CUDA", 1},
+		"cpu":    {"CPU", 0},
+		"CUDA:0": DEFAULT_DEVICE,
+	} {
+		device, err := ParseDevice(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device != expected {
+			t.Fatalf("%s: got %s", s, device)
+		}
+	}
+	for _, s := range []string{"", ":1", "CUDA:x", "CUDA:-1"} {
+		if _, err := ParseDevice(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestDevicePool(t *testing.T) {
+	if _, err := NewDevicePool(); err == nil {
+		t.Fatal("expected an error for an empty pool")
+	}
+	if _, err := NewDevicePool(Device{"CPU", 0}, Device{"CPU", 0}); err == nil {
+		t.Fatal("expected an error for a duplicate device")
+	}
+	pool, err := NewDevicePool(Device{"CUDA", 0}, Device{"CUDA", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// concurrent proofs are spread over the devices
+	a, err := pool.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.device == b.device {
+		t.Fatalf("both proofs run on %s", a.device)
+	}
+
+	// a third proof waits for a busy device
+	acquired := make(chan *deviceSlot)
+	go func() {
+		slot, err := pool.acquire(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- slot
+	}()
+	for q := pool.Queued(); q[0]+q[1] != 3; q = pool.Queued() {
+	}
+	select {
+	case <-acquired:
+		t.Fatal("device acquired while busy")
+	default:
+	}
+	pool.release(a)
+	c := <-acquired
+	if c.device != a.device {
+		t.Fatalf("queued proof runs on %s not %s", c.device, a.device)
+	}
+
+	// a cancelled proof leaves its queue
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := pool.acquire(cancelled); err != context.Canceled {
+		t.Fatalf("got %v", err)
+	}
+	if q := pool.Queued(); q[0] != 1 || q[1] != 1 {
+		t.Fatalf("queues %v", q)
+	}
+
+	pk := ProvingKey{Pool: pool}
+	go func() {
+		pool.release(c)
+		pool.release(b)
+	}()
+	device, release, err := pk.device(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if device != a.device && device != b.device {
+		t.Fatalf("got %s", device)
+	}
+	pk = ProvingKey{}
+	if device, _, _ := pk.device(ctx); device != DEFAULT_DEVICE {
+		t.Fatalf("got %s", device)
+	}
+}
@@ -3,20 +3,28 @@
 package gpu
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	cs "github.com/consensys/gnark/constraint/bls12-381"
 
 	icicle_core "github.com/ingonyama-zk/icicle-gnark/v3/wrappers/golang/core"
 	icicle_runtime "github.com/ingonyama-zk/icicle-gnark/v3/wrappers/golang/runtime"
+
+	"github.com/eon-protocol/eonark/observer"
 )
 
 type deviceInfo struct {
 	Device icicle_runtime.Device
+	device Device
+	lock   *deviceLock
 
 	Streams []icicle_runtime.Stream
 
@@ -38,6 +46,9 @@ type deviceInfo struct {
 
 	bigW fr.Element
 
+	// the cache of the proving key and every running proof hold a reference
+	refs atomic.Int32
+
 	G1LagPrecomp  icicle_core.DeviceSlice
 	hasLagPrecomp bool
 	MsmCfgLag     icicle_core.MSMConfig
@@ -53,19 +64,173 @@ type ProvingKey struct {
 	Kzg         kzg.ProvingKey
 	KzgLagrange kzg.ProvingKey
 	Vk          *plonkbls12381.VerifyingKey
-	deviceInfo  *deviceInfo
+	Trace       *plonkbls12381.Trace
+	// Device proves on one device, Pool on any of its devices. The zero
+	// value proves on DEFAULT_DEVICE.
+	Device     Device
+	Pool       *DevicePool
+	Observer   observer.ProverObserver
+	deviceInfo *deviceInfo
+	cache      *deviceCache
+	obs        *observer.Proof
+}
+
+// deviceCache keeps the device state of a proving key between proofs, one
+// entry per device.
+type deviceCache struct {
+	mu     sync.Mutex
+	infos  map[Device]*deviceInfo
+	closed bool
 }
 
+// WrapProvingKey returns a proving key that sets up each device once and
+// reuses it for every proof. Its device memory is freed by Close.
 func WrapProvingKey(pk *plonkbls12381.ProvingKey) (*ProvingKey, error) {
-	warmUpDevice()
 	return &ProvingKey{
 		Kzg:         pk.Kzg,
 		KzgLagrange: pk.KzgLagrange,
 		Vk:          pk.Vk,
-		deviceInfo:  &deviceInfo{},
+		cache:       &deviceCache{infos: make(map[Device]*deviceInfo)},
 	}, nil
 }
 
+// Close frees the device state of pk once the running proofs are done. A
+// proving key not made by WrapProvingKey keeps nothing to free.
+func (pk *ProvingKey) Close() error {
+	if pk.cache == nil {
+		return nil
+	}
+	pk.cache.mu.Lock()
+	defer pk.cache.mu.Unlock()
+	pk.cache.closed = true
+	var errs []error
+	for device, info := range pk.cache.infos {
+		if err := info.release(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", device, err))
+		}
+	}
+	clear(pk.cache.infos)
+	return errors.Join(errs...)
+}
+
+// acquireDeviceInfo returns the device state for proving spr on device, set
+// up on first use. The caller must release it.
+func (pk *ProvingKey) acquireDeviceInfo(spr *cs.SparseR1CS, device Device) (*deviceInfo, error) {
+	n := int(fft.NewDomain(uint64(spr.GetNbConstraints()+len(spr.Public)), fft.WithoutPrecompute()).Cardinality)
+	if pk.cache == nil {
+		return pk.newDeviceInfo(spr, device, 1)
+	}
+	pk.cache.mu.Lock()
+	defer pk.cache.mu.Unlock()
+	if pk.cache.closed {
+		return nil, errors.New("proving key is closed")
+	}
+	if info, ok := pk.cache.infos[device]; ok {
+		if info.N == n {
+			info.refs.Add(1)
+			return info, nil
+		}
+		delete(pk.cache.infos, device)
+		if err := info.release(); err != nil {
+			return nil, err
+		}
+	}
+	info, err := pk.newDeviceInfo(spr, device, 2)
+	if err != nil {
+		return nil, err
+	}
+	pk.cache.infos[device] = info
+	return info, nil
+}
+
+func (pk *ProvingKey) newDeviceInfo(spr *cs.SparseR1CS, device Device, refs int32) (*deviceInfo, error) {
+	if err := warmUpDevice(device); err != nil {
+		return nil, err
+	}
+	local := *pk
+	if err := local.setupDevicePointers(spr, device); err != nil {
+		if local.deviceInfo != nil {
+			local.deviceInfo.free()
+		}
+		return nil, err
+	}
+	local.deviceInfo.refs.Store(refs)
+	return local.deviceInfo, nil
+}
+
+// backend is where the steps of a proof with pk run.
+func (pk *ProvingKey) backend() string {
+	if pk.deviceInfo != nil {
+		return observer.BACKEND_ICICLE
+	}
+	return observer.BACKEND_CPU
+}
+
+// observeMSM reports an MSM of size scalars, which the device path copies to
+// the device first.
+func (pk *ProvingKey) observeMSM(size int) {
+	if pk.deviceInfo != nil {
+		pk.obs.Transfer(true, size*fr.Bytes)
+	}
+	pk.obs.MSM(pk.backend(), size)
+}
+
+func (di *deviceInfo) fail(op string, st icicle_runtime.EIcicleError) error {
+	return deviceError(op, di.device, st)
+}
+
+// checkDomain fails unless the NTT domain of the device is set and holds
+// size points. ICICLE aborts the process on such calls instead of failing.
+func (di *deviceInfo) checkDomain(op string, size int) error {
+	if di.lock.domain == 0 {
+		return &DeviceError{Op: op, Device: di.device, Code: int(icicle_runtime.InvalidArgument), Reason: "NTT domain is not initialized"}
+	}
+	if uint64(size) > di.lock.domain {
+		return &DeviceError{Op: op, Device: di.device, Code: int(icicle_runtime.InvalidArgument), Reason: fmt.Sprintf("NTT size %d exceeds the domain size %d", size, di.lock.domain)}
+	}
+	return nil
+}
+
+// release drops a reference and frees the device memory with the last one.
+func (di *deviceInfo) release() error {
+	if di.refs.Add(-1) > 0 {
+		return nil
+	}
+	return di.free()
+}
+
+func (di *deviceInfo) free() error {
+	var errs []error
+	done := make(chan struct{})
+	icicle_runtime.RunOnDevice(&di.Device, func(args ...any) {
+		defer close(done)
+		for _, slice := range []*icicle_core.DeviceSlice{
+			&di.G1Device.G1, &di.G1Device.G1Lagrange,
+			&di.CosetTable, &di.CosetTableRev,
+			&di.BigTwiddlesN, &di.BigTwiddlesNRev,
+			&di.G1LagPrecomp, &di.G1Precomp,
+		} {
+			if slice.AsUnsafePointer() == nil {
+				continue
+			}
+			if st := slice.Free(); st != icicle_runtime.Success {
+				errs = append(errs, fmt.Errorf("free: %s", st.AsString()))
+			}
+		}
+		for _, stream := range di.Streams {
+			if stream == nil {
+				continue
+			}
+			if st := icicle_runtime.DestroyStream(stream); st != icicle_runtime.Success {
+				errs = append(errs, fmt.Errorf("destroy stream: %s", st.AsString()))
+			}
+		}
+		di.Streams = nil
+	})
+	<-done
+	return errors.Join(errs...)
+}
+
 func (di *deviceInfo) ensureHostCosetTables(d0 *fft.Domain) ([]fr.Element, []fr.Element) {
 	di.onceCoset.Do(func() {
 		tab, _ := d0.CosetTable() // [1, s, s^2, ...] ，s = d1.FrMultiplicativeGen
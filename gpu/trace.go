@@ -0,0 +1,27 @@
+package gpu
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/iop"
+	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+)
+
+// CloneTrace returns a copy of trace that a prover may mutate in place. The
+// permutation S is shared, the provers only read it.
+func CloneTrace(trace *plonkbls12381.Trace) *plonkbls12381.Trace {
+	ret := plonkbls12381.Trace{
+		Ql:  trace.Ql.Clone(),
+		Qr:  trace.Qr.Clone(),
+		Qm:  trace.Qm.Clone(),
+		Qo:  trace.Qo.Clone(),
+		Qk:  trace.Qk.Clone(),
+		Qcp: make([]*iop.Polynomial, len(trace.Qcp)),
+		S1:  trace.S1.Clone(),
+		S2:  trace.S2.Clone(),
+		S3:  trace.S3.Clone(),
+		S:   trace.S,
+	}
+	for i := range trace.Qcp {
+		ret.Qcp[i] = trace.Qcp[i].Clone()
+	}
+	return &ret
+}
@@ -1,6 +1,9 @@
 package eonark
 
 import (
+	"context"
+	"errors"
+
 	"github.com/consensys/gnark/backend"
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	"github.com/consensys/gnark/backend/witness"
@@ -10,6 +13,14 @@ import (
 )
 
 func Prove(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+	return ProveWithTrace(spr, pk, nil, w, opts...)
+}
+
+func ProveWithTrace(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, trace *plonkbls12381.Trace, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+	return proveWithConfig(context.Background(), spr, pk, trace, ProverConfig{}, w, opts...)
+}
+
+func proveWithConfig(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, trace *plonkbls12381.Trace, cfg ProverConfig, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
 	opt, err := backend.NewProverConfig(opts...)
 	if err != nil {
 		return nil, err
@@ -20,9 +31,37 @@ func Prove(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, w witness.Witness,
 			Kzg:         pk.Kzg,
 			KzgLagrange: pk.KzgLagrange,
 			Vk:          pk.Vk,
+			Trace:       trace,
+			Device:      cfg.Device,
+			Pool:        cfg.Pool,
+			Observer:    cfg.Observer,
 		}
-		return gpu.Prove(spr, gpk, w, opts...)
+		return proveOnDevice(ctx, spr, gpk, cfg, w, opts...)
 	}
 
-	return prove(spr, pk, w, opts...)
+	proof, err := prove(ctx, spr, pk, trace, cfg.Observer, w, opts...)
+	if err == nil {
+		cfg.report(PROVER_CPU, nil)
+	}
+	return proof, err
+}
+
+// proveOnDevice proves with icicle. When a device step fails the whole proof
+// is redone by the CPU prover.
+func proveOnDevice(ctx context.Context, spr *cs.SparseR1CS, gpk *gpu.ProvingKey, cfg ProverConfig, w witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+	proof, err := gpu.ProveContext(ctx, spr, gpk, w, opts...)
+	var derr *gpu.DeviceError
+	if !errors.As(err, &derr) {
+		if err == nil {
+			cfg.report(PROVER_ICICLE, nil)
+		}
+		return proof, err
+	}
+	pk := &plonkbls12381.ProvingKey{Kzg: gpk.Kzg, KzgLagrange: gpk.KzgLagrange, Vk: gpk.Vk}
+	proof, cerr := prove(ctx, spr, pk, gpk.Trace, cfg.Observer, w, opts...)
+	if cerr != nil {
+		return nil, cerr
+	}
+	cfg.report(PROVER_CPU, err)
+	return proof, nil
 }
@@ -0,0 +1,236 @@
+package eonark
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+type PublicInputs []fr.Element
+
+// proofJSON and vkJSON written before keys had any number of commitments
+// have a single BSB, CQC, QC and CI instead of lists.
+type proofJSON struct {
+	CW1, CW2, CW3, CH1, CH2, CH3, CPZ, HBP, HZO string
+	BSB                                         oneOrMany[string]
+	CZO, COL, CVL, CVR, CVO, CS1, CS2           string
+	CQC                                         oneOrMany[string]
+}
+
+// vkJSON without NP is a key of NUM_PUBLIC public inputs.
+type vkJSON struct {
+	S1, S2, S3, QL, QR, QM, QO, QK string
+	QC                             oneOrMany[string]
+	CI                             oneOrMany[uint32]
+	SZ                             uint8
+	NP                             uint32
+}
+
+type oneOrMany[T any] []T
+
+func (me *oneOrMany[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*me = nil
+		return nil
+	}
+	var one T
+	if err := json.Unmarshal(data, &one); err == nil {
+		*me = oneOrMany[T]{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]T)(me))
+}
+
+func (me *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(proofJSON{
+		CW1: encodeG1(&me.CW1), CW2: encodeG1(&me.CW2), CW3: encodeG1(&me.CW3),
+		CH1: encodeG1(&me.CH1), CH2: encodeG1(&me.CH2), CH3: encodeG1(&me.CH3),
+		CPZ: encodeG1(&me.CPZ), BSB: encodeG1s(me.BSB), HBP: encodeG1(&me.HBP), HZO: encodeG1(&me.HZO),
+		CZO: me.CZO.Text(10), COL: me.COL.Text(10), CVL: me.CVL.Text(10), CVR: me.CVR.Text(10),
+		CVO: me.CVO.Text(10), CS1: me.CS1.Text(10), CS2: me.CS2.Text(10), CQC: PublicInputs(me.CQC).Strings(),
+	})
+}
+
+func (me *Proof) UnmarshalJSON(data []byte) error {
+	var val proofJSON
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	var proof Proof
+	for _, v := range []struct {
+		name string
+		src  string
+		dst  *bls12381.G1Affine
+	}{
+		{"CW1", val.CW1, &proof.CW1}, {"CW2", val.CW2, &proof.CW2}, {"CW3", val.CW3, &proof.CW3},
+		{"CH1", val.CH1, &proof.CH1}, {"CH2", val.CH2, &proof.CH2}, {"CH3", val.CH3, &proof.CH3},
+		{"CPZ", val.CPZ, &proof.CPZ}, {"HBP", val.HBP, &proof.HBP}, {"HZO", val.HZO, &proof.HZO},
+	} {
+		if err := decodeG1(v.dst, v.src); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+	for _, v := range []struct {
+		name string
+		src  string
+		dst  *fr.Element
+	}{
+		{"CZO", val.CZO, &proof.CZO}, {"COL", val.COL, &proof.COL}, {"CVL", val.CVL, &proof.CVL},
+		{"CVR", val.CVR, &proof.CVR}, {"CVO", val.CVO, &proof.CVO}, {"CS1", val.CS1, &proof.CS1},
+		{"CS2", val.CS2, &proof.CS2},
+	} {
+		if err := decodeFr(v.dst, v.src); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+	if len(val.BSB) != len(val.CQC) {
+		return errors.New("invalid number of commitments")
+	}
+	var err error
+	if proof.BSB, err = decodeG1s("BSB", val.BSB); err != nil {
+		return err
+	}
+	var cqc PublicInputs
+	if err := cqc.SetStrings(val.CQC...); err != nil {
+		return fmt.Errorf("CQC: %w", err)
+	}
+	proof.CQC = cqc
+	*me = proof
+	return nil
+}
+
+func (me *Vk) MarshalJSON() ([]byte, error) {
+	if me.testSRS != nil {
+		return nil, errors.New("key of a test SRS")
+	}
+	return json.Marshal(vkJSON{
+		S1: encodeG1(&me.S1), S2: encodeG1(&me.S2), S3: encodeG1(&me.S3),
+		QL: encodeG1(&me.QL), QR: encodeG1(&me.QR), QM: encodeG1(&me.QM),
+		QO: encodeG1(&me.QO), QK: encodeG1(&me.QK), QC: encodeG1s(me.QC),
+		CI: append(oneOrMany[uint32]{}, me.CI...), SZ: me.SZ, NP: me.NP,
+	})
+}
+
+func (me *Vk) UnmarshalJSON(data []byte) error {
+	var val vkJSON
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	if len(val.QC) != len(val.CI) {
+		return errors.New("invalid number of commitments")
+	}
+	if int(val.SZ) >= len(SRS_LK_HASH) {
+		return fmt.Errorf("invalid domain size 2^%d", val.SZ)
+	}
+	vk := Vk{CI: val.CI, SZ: val.SZ, NP: val.NP}
+	if vk.NP == 0 {
+		vk.NP = NUM_PUBLIC
+	}
+	for _, v := range []struct {
+		name string
+		src  string
+		dst  *bls12381.G1Affine
+	}{
+		{"S1", val.S1, &vk.S1}, {"S2", val.S2, &vk.S2}, {"S3", val.S3, &vk.S3},
+		{"QL", val.QL, &vk.QL}, {"QR", val.QR, &vk.QR}, {"QM", val.QM, &vk.QM},
+		{"QO", val.QO, &vk.QO}, {"QK", val.QK, &vk.QK},
+	} {
+		if err := decodeG1(v.dst, v.src); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+	var err error
+	if vk.QC, err = decodeG1s("QC", val.QC); err != nil {
+		return err
+	}
+	*me = vk
+	return nil
+}
+
+func (me PublicInputs) Strings() []string {
+	ret := make([]string, len(me))
+	for i := range me {
+		ret[i] = me[i].Text(10)
+	}
+	return ret
+}
+
+func (me PublicInputs) String() string {
+	return strings.Join(me.Strings(), ",")
+}
+
+func (me *PublicInputs) SetStrings(vals ...string) error {
+	pub := make(PublicInputs, len(vals))
+	for i, v := range vals {
+		if err := decodeFr(&pub[i], v); err != nil {
+			return fmt.Errorf("public input %d: %w", i, err)
+		}
+	}
+	*me = pub
+	return nil
+}
+
+func (me PublicInputs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(me.Strings())
+}
+
+func (me *PublicInputs) UnmarshalJSON(data []byte) error {
+	var vals []string
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return err
+	}
+	return me.SetStrings(vals...)
+}
+
+func encodeG1(val *bls12381.G1Affine) string {
+	b := val.Bytes()
+	return "0x" + hex.EncodeToString(b[:])
+}
+
+func encodeG1s(vals []bls12381.G1Affine) []string {
+	ret := make([]string, len(vals))
+	for i := range vals {
+		ret[i] = encodeG1(&vals[i])
+	}
+	return ret
+}
+
+func decodeG1s(name string, s []string) ([]bls12381.G1Affine, error) {
+	ret := make([]bls12381.G1Affine, len(s))
+	for i, v := range s {
+		if err := decodeG1(&ret[i], v); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+	}
+	return ret, nil
+}
+
+func decodeG1(val *bls12381.G1Affine, s string) error {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(b) != bls12381.SizeOfG1AffineCompressed {
+		return fmt.Errorf("invalid compressed G1 length %d", len(b))
+	}
+	_, err = val.SetBytes(b)
+	return err
+}
+
+func decodeFr(val *fr.Element, s string) error {
+	var i big.Int
+	if _, ok := i.SetString(s, 10); !ok {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	if i.Sign() < 0 || i.Cmp(fr.Modulus()) >= 0 {
+		return fmt.Errorf("%s is not a canonical field element", s)
+	}
+	val.SetBigInt(&i)
+	return nil
+}
@@ -0,0 +1,90 @@
+package eonark_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
+
+func TestJSON(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 6))
+	if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	publics, _, proof, err := pk.Prove(&publicsCircuit{Publics: []frontend.Variable{1, 2}, Sum: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var readproof eonark.Proof
+	if err := json.Unmarshal(data, &readproof); err != nil || !readproof.Equal(proof) {
+		t.Fatalf("proof changed by JSON: %v", err)
+	}
+	// proofs written before any number of commitments have single values
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["BSB"], fields["CQC"] = fields["BSB"].([]any)[0], fields["CQC"].([]any)[0]
+	legacy, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(legacy, &readproof); err != nil || !readproof.Equal(proof) {
+		t.Fatalf("legacy JSON proof changed: %v", err)
+	}
+	if err := json.Unmarshal(bytes.Replace(data, []byte(`"CQC":[`), []byte(`"CQC":["0",`), 1), &readproof); err == nil {
+		t.Fatal("proof accepted with more openings than commitments")
+	}
+
+	vk := pk.Vk()
+	vk.SetTestSRS(nil)
+	if data, err = json.Marshal(&vk); err != nil {
+		t.Fatal(err)
+	}
+	var readvk eonark.Vk
+	if err := json.Unmarshal(data, &readvk); err != nil || !readvk.Equal(&vk) {
+		t.Fatalf("vk changed by JSON: %v", err)
+	}
+	fields = nil
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, sz := range []int{25, 64, 255} {
+		fields["SZ"] = sz
+		forged, err := json.Marshal(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(forged, &readvk); err == nil {
+			t.Fatalf("vk accepted with a domain of size 2^%d", sz)
+		}
+	}
+
+	if data, err = json.Marshal(eonark.PublicInputs(publics)); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["1","2"]` {
+		t.Fatalf("public inputs encoded as %s", data)
+	}
+	var readpublics eonark.PublicInputs
+	if err := json.Unmarshal(data, &readpublics); err != nil || !slices.Equal(readpublics, publics) {
+		t.Fatalf("public inputs changed by JSON: %v", err)
+	}
+	for _, v := range []string{`["-1"]`, `["0x1"]`, `["` + fr.Modulus().String() + `"]`, `[1]`} {
+		if err := json.Unmarshal([]byte(v), &readpublics); err == nil {
+			t.Fatalf("public inputs %s accepted", v)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package eonark
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/iop"
+	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	"github.com/consensys/gnark/frontend"
+)
+
+const KEY_STORE_MAGIC = "EONARKPK"
+const KEY_STORE_VERSION = 1
+const KEY_STORE_HEADER_SIZE = len(KEY_STORE_MAGIC) + 2 + 1 + sha256.Size + 8
+
+const key_store_flag_trace = 1
+
+type KeyStore struct {
+	Dir    string
+	Trace  bool
+	Source SRSSource
+}
+
+var KEY_STORE = KeyStore{
+	Dir:   path.Join(DATA_CACHE_DIR, "keys"),
+	Trace: true,
+}
+
+func LoadOrCompile(name string, circuit frontend.Circuit) (*Pk, error) {
+	return KEY_STORE.LoadOrCompile(name, circuit)
+}
+
+// LoadOrCompile returns the key saved under name, or compiles circuit and
+// saves its key under name. The circuit is not compiled to find the key, so
+// name must change whenever the circuit does.
+func (me *KeyStore) LoadOrCompile(name string, circuit frontend.Circuit) (*Pk, error) {
+	if address, err := me.alias(name); err == nil {
+		if pk, err := me.Load(address); err == nil {
+			return pk, nil
+		}
+	}
+	var pk Pk
+	pk.SetSRSSource(me.Source)
+	if err := pk.Compile(circuit); err != nil {
+		return nil, err
+	}
+	if me.Trace {
+		if err := pk.Precompute(); err != nil {
+			return nil, err
+		}
+	}
+	if err := me.Save(&pk); err != nil {
+		return nil, err
+	}
+	vk := pk.Vk()
+	address := vk.Address()
+	return &pk, os.WriteFile(me.aliasPath(name), []byte(address.Text(16)), 0o644)
+}
+
+func (me *KeyStore) Path(address fr.Element) string {
+	return path.Join(me.Dir, address.Text(16)+".PK")
+}
+
+func (me *KeyStore) Save(pk *Pk) error {
+	if err := os.MkdirAll(me.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	vk := pk.Vk()
+	pathpk := me.Path(vk.Address())
+	file, err := os.CreateTemp(me.Dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	var flags uint8
+	if pk.trace != nil {
+		flags |= key_store_flag_trace
+	}
+	if _, err := file.Write(make([]byte, KEY_STORE_HEADER_SIZE)); err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	buf := bufio.NewWriter(file)
+	w := io.MultiWriter(buf, hasher)
+	n, err := pk.WriteTo(w)
+	if err != nil {
+		return err
+	}
+	if pk.trace != nil {
+		m, err := writeTrace(w, pk.trace)
+		if err != nil {
+			return err
+		}
+		n += m
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	header := binary.BigEndian.AppendUint16([]byte(KEY_STORE_MAGIC), KEY_STORE_VERSION)
+	header = append(header, flags)
+	header = append(header, hasher.Sum(nil)...)
+	header = binary.BigEndian.AppendUint64(header, uint64(n))
+	if _, err := file.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), pathpk)
+}
+
+func (me *KeyStore) Load(address fr.Element) (*Pk, error) {
+	file, err := os.Open(me.Path(address))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	header := make([]byte, KEY_STORE_HEADER_SIZE)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(KEY_STORE_MAGIC)]) != KEY_STORE_MAGIC {
+		return nil, errors.New("invalid key store magic")
+	}
+	header = header[len(KEY_STORE_MAGIC):]
+	if version := binary.BigEndian.Uint16(header); version != KEY_STORE_VERSION {
+		return nil, fmt.Errorf("unsupported key store version %d", version)
+	}
+	flags := header[2]
+	sum := header[3 : 3+sha256.Size]
+	size := binary.BigEndian.Uint64(header[3+sha256.Size:])
+	hasher := sha256.New()
+	payload := io.TeeReader(io.LimitReader(r, int64(size)), hasher)
+	var pk Pk
+	if _, err := pk.ReadFrom(payload); err != nil {
+		return nil, err
+	}
+	if flags&key_store_flag_trace != 0 {
+		if pk.trace, err = readTrace(payload); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := io.Copy(io.Discard, payload); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hasher.Sum(nil), sum) {
+		return nil, errors.New("key store sha256 mismatch")
+	}
+	if vk := pk.Vk(); vk.Address() != address {
+		return nil, errors.New("key store address mismatch")
+	}
+	pk.SetSRSSource(me.Source)
+	return &pk, nil
+}
+
+// alias returns the address of the key saved under name by LoadOrCompile.
+func (me *KeyStore) alias(name string) (fr.Element, error) {
+	var address fr.Element
+	addr, err := os.ReadFile(me.aliasPath(name))
+	if err != nil {
+		return address, err
+	}
+	_, err = address.SetString("0x" + string(addr))
+	return address, err
+}
+
+// aliasPath is the file holding the address of the key saved under name
+// for the SRS in use.
+func (me *KeyStore) aliasPath(name string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(name))
+	hasher.Write([]byte(SRS_CK_HASH))
+	return path.Join(me.Dir, hex.EncodeToString(hasher.Sum(nil))+".ADDR")
+}
+
+func writeTrace(w io.Writer, trace *plonkbls12381.Trace) (int64, error) {
+	var n int64
+	polys := []*iop.Polynomial{trace.Ql, trace.Qr, trace.Qm, trace.Qo, trace.Qk, trace.S1, trace.S2, trace.S3}
+	polys = append(polys, trace.Qcp...)
+	if err := binary.Write(w, binary.BigEndian, uint32(len(trace.Qcp))); err != nil {
+		return n, err
+	}
+	n += 4
+	for _, p := range polys {
+		m, err := p.WriteTo(w)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, uint64(len(trace.S))); err != nil {
+		return n, err
+	}
+	if err := binary.Write(w, binary.BigEndian, trace.S); err != nil {
+		return n, err
+	}
+	return n + 8 + 8*int64(len(trace.S)), nil
+}
+
+func readTrace(r io.Reader) (*plonkbls12381.Trace, error) {
+	var nqcp uint32
+	if err := binary.Read(r, binary.BigEndian, &nqcp); err != nil {
+		return nil, err
+	}
+	polys := make([]*iop.Polynomial, 8+nqcp)
+	for i := range polys {
+		polys[i] = new(iop.Polynomial)
+		if _, err := polys[i].ReadFrom(r); err != nil {
+			return nil, err
+		}
+	}
+	var ns uint64
+	if err := binary.Read(r, binary.BigEndian, &ns); err != nil {
+		return nil, err
+	}
+	if ns > uint64(3*polys[0].Size()) {
+		return nil, errors.New("invalid trace permutation size")
+	}
+	trace := plonkbls12381.Trace{
+		Ql:  polys[0],
+		Qr:  polys[1],
+		Qm:  polys[2],
+		Qo:  polys[3],
+		Qk:  polys[4],
+		S1:  polys[5],
+		S2:  polys[6],
+		S3:  polys[7],
+		Qcp: polys[8:],
+		S:   make([]int64, ns),
+	}
+	if err := binary.Read(r, binary.BigEndian, trace.S); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
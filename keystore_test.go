@@ -0,0 +1,99 @@
+package eonark_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
+
+func TestKeyStore(t *testing.T) {
+	src := pinTestSRS(t, 6)
+	store := eonark.KeyStore{Dir: t.TempDir(), Trace: true, Source: src}
+	circuit := publicsCircuit{Publics: make([]frontend.Variable, 2)}
+	pk, err := store.LoadOrCompile("publics-2", &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	address := vk.Address()
+	aliases, err := filepath.Glob(path.Join(store.Dir, "*.ADDR"))
+	if err != nil || len(aliases) != 1 {
+		t.Fatalf("%d key aliases saved: %v", len(aliases), err)
+	}
+	if alias, err := os.ReadFile(aliases[0]); err != nil || string(alias) != address.Text(16) {
+		t.Fatalf("key alias %q of %s: %v", alias, address.Text(16), err)
+	}
+
+	// the alias leads to the saved key, trace included
+	loaded, err := store.LoadOrCompile("publics-2", &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == pk {
+		t.Fatal("key compiled again")
+	}
+	if lvk := loaded.Vk(); lvk.Address() != address {
+		t.Fatal("loaded key differs")
+	}
+	publics, _, proof, err := loaded.Prove(&publicsCircuit{Publics: []frontend.Variable{1, 2}, Sum: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+
+	// a flipped payload byte fails the header checksum
+	pathpk := store.Path(address)
+	data, err := os.ReadFile(pathpk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(pathpk, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(address); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("tampered key loaded: %v", err)
+	}
+	// and the alias no longer short-cuts the compilation
+	if pk, err = store.LoadOrCompile("publics-2", &circuit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(address); err != nil {
+		t.Fatalf("key not saved again: %v", err)
+	}
+
+	// a key under another name has its own alias
+	other, err := store.LoadOrCompile("publics-3", &publicsCircuit{Publics: make([]frontend.Variable, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ovk := other.Vk()
+	if ovk.Address() == address {
+		t.Fatal("keys of different names share an address")
+	}
+	if pk, err = store.LoadOrCompile("publics-2", &circuit); err != nil {
+		t.Fatal(err)
+	}
+	if lvk := pk.Vk(); lvk.Address() != address {
+		t.Fatal("alias of another name followed")
+	}
+
+	// an alias to a missing key compiles again
+	if err := os.WriteFile(aliases[0], []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if pk, err = store.LoadOrCompile("publics-2", &circuit); err != nil {
+		t.Fatal(err)
+	}
+	if lvk := pk.Vk(); lvk.Address() != address {
+		t.Fatal("key compiled again differs")
+	}
+}
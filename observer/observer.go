@@ -0,0 +1,146 @@
+// Package observer defines the hooks through which provers report their
+// progress: proofs, proving rounds, MSM and NTT sizes and device transfers.
+package observer
+
+import (
+	"context"
+	"sync"
+)
+
+const BACKEND_CPU = "cpu"
+const BACKEND_ICICLE = "icicle"
+
+type Round int
+
+const (
+	ROUND_SETUP Round = iota
+	ROUND_SOLVE
+	ROUND_LRO
+	ROUND_GAMMA_BETA
+	ROUND_Z
+	ROUND_QUOTIENT
+	ROUND_LINEARIZATION
+	ROUND_OPENING
+	NUM_ROUNDS
+)
+
+var ROUND_NAMES = [NUM_ROUNDS]string{"setup", "solve", "lro", "gamma_beta", "z", "quotient", "linearization", "opening"}
+
+func (me Round) String() string {
+	if me < 0 || me >= NUM_ROUNDS {
+		return "unknown"
+	}
+	return ROUND_NAMES[me]
+}
+
+// ProverObserver receives the progress of proofs, from several goroutines at
+// once. The context returned by ProofStart is passed to every later call of
+// the same proof, the one returned by RoundStart to the matching RoundEnd.
+type ProverObserver interface {
+	ProofStart(ctx context.Context, backend string, nbConstraints int) context.Context
+	ProofEnd(ctx context.Context, err error)
+	RoundStart(ctx context.Context, round Round, backend string) context.Context
+	RoundEnd(ctx context.Context, round Round, err error)
+	// size is the number of points or scalars
+	MSM(ctx context.Context, backend string, size int)
+	NTT(ctx context.Context, backend string, size int)
+	// bytes copied to the device when toDevice, from it otherwise
+	Transfer(ctx context.Context, toDevice bool, bytes int)
+}
+
+// Nop ignores everything, embed it to observe only some events.
+type Nop struct{}
+
+func (Nop) ProofStart(ctx context.Context, _ string, _ int) context.Context { return ctx }
+func (Nop) ProofEnd(context.Context, error)                                 {}
+func (Nop) RoundStart(ctx context.Context, _ Round, _ string) context.Context {
+	return ctx
+}
+func (Nop) RoundEnd(context.Context, Round, error) {}
+func (Nop) MSM(context.Context, string, int)       {}
+func (Nop) NTT(context.Context, string, int)       {}
+func (Nop) Transfer(context.Context, bool, int)    {}
+
+// Proof reports the events of one proof. A nil *Proof reports nothing.
+type Proof struct {
+	observer ProverObserver
+	ctx      context.Context
+	backend  string
+
+	mu     sync.Mutex
+	rounds [NUM_ROUNDS]context.Context
+	ended  [NUM_ROUNDS]bool
+}
+
+// Start reports the start of a proof to o, nil when o is nil.
+func Start(ctx context.Context, o ProverObserver, backend string, nbConstraints int) *Proof {
+	if o == nil {
+		return nil
+	}
+	return &Proof{observer: o, ctx: o.ProofStart(ctx, backend, nbConstraints), backend: backend}
+}
+
+func (me *Proof) End(err error) {
+	if me != nil {
+		me.observer.ProofEnd(me.ctx, err)
+	}
+}
+
+// RoundStart starts round once its inputs are ready. A round is started at
+// most once.
+func (me *Proof) RoundStart(round Round) {
+	if me == nil {
+		return
+	}
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.rounds[round] == nil {
+		me.rounds[round] = me.observer.RoundStart(me.ctx, round, me.backend)
+	}
+}
+
+// RoundEnd ends round if it was started and not ended yet.
+func (me *Proof) RoundEnd(round Round, err error) {
+	if me == nil {
+		return
+	}
+	me.mu.Lock()
+	ctx := me.rounds[round]
+	if me.ended[round] {
+		ctx = nil
+	}
+	if ctx != nil {
+		me.ended[round] = true
+	}
+	me.mu.Unlock()
+	if ctx != nil {
+		me.observer.RoundEnd(ctx, round, err)
+	}
+}
+
+// Round returns f ending round with its error.
+func (me *Proof) Round(round Round, f func() error) func() error {
+	return func() error {
+		err := f()
+		me.RoundEnd(round, err)
+		return err
+	}
+}
+
+func (me *Proof) MSM(backend string, size int) {
+	if me != nil {
+		me.observer.MSM(me.ctx, backend, size)
+	}
+}
+
+func (me *Proof) NTT(backend string, size int) {
+	if me != nil {
+		me.observer.NTT(me.ctx, backend, size)
+	}
+}
+
+func (me *Proof) Transfer(toDevice bool, bytes int) {
+	if me != nil {
+		me.observer.Transfer(me.ctx, toDevice, bytes)
+	}
+}
@@ -0,0 +1,163 @@
+// Package telemetry exports prover events as Prometheus metrics and
+// OpenTelemetry spans.
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/eon-protocol/eonark/observer"
+)
+
+const TRACER_NAME = "github.com/eon-protocol/eonark"
+
+// Telemetry is an observer.ProverObserver. Every proof is an "eonark.prove"
+// span with one child span per round, its MSMs, NTTs and transfers are
+// events of the proof span.
+type Telemetry struct {
+	tracer trace.Tracer
+
+	proofs       *prometheus.CounterVec
+	proofSeconds *prometheus.HistogramVec
+	roundSeconds *prometheus.HistogramVec
+	msmSize      *prometheus.HistogramVec
+	nttSize      *prometheus.HistogramVec
+	deviceBytes  *prometheus.CounterVec
+}
+
+// started is kept in the context of a proof or round until it ends.
+type started struct {
+	at      time.Time
+	backend string
+}
+
+type startedKey struct{}
+
+func startedOf(ctx context.Context) started {
+	ret, _ := ctx.Value(startedKey{}).(started)
+	return ret
+}
+
+// New registers the metrics with reg, prometheus.DefaultRegisterer when nil,
+// and starts spans with tracer, the global one when nil.
+func New(reg prometheus.Registerer, tracer trace.Tracer) (*Telemetry, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	if tracer == nil {
+		tracer = otel.Tracer(TRACER_NAME)
+	}
+	sizes := prometheus.ExponentialBuckets(1<<8, 4, 10)
+	seconds := prometheus.ExponentialBuckets(0.005, 2, 16)
+	ret := Telemetry{
+		tracer: tracer,
+		proofs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eonark_prover_proofs_total",
+			Help: "Number of proofs by backend and status.",
+		}, []string{"backend", "status"}),
+		proofSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eonark_prover_proof_seconds",
+			Help:    "Duration of proofs.",
+			Buckets: seconds,
+		}, []string{"backend"}),
+		roundSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eonark_prover_round_seconds",
+			Help:    "Duration of proving rounds, from the time their inputs are ready.",
+			Buckets: seconds,
+		}, []string{"round", "backend"}),
+		msmSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eonark_prover_msm_size",
+			Help:    "Number of points of MSMs.",
+			Buckets: sizes,
+		}, []string{"backend"}),
+		nttSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "eonark_prover_ntt_size",
+			Help:    "Number of scalars of NTTs.",
+			Buckets: sizes,
+		}, []string{"backend"}),
+		deviceBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eonark_prover_device_bytes_total",
+			Help: "Bytes copied to and from devices.",
+		}, []string{"direction"}),
+	}
+	for _, c := range []prometheus.Collector{ret.proofs, ret.proofSeconds, ret.roundSeconds, ret.msmSize, ret.nttSize, ret.deviceBytes} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return &ret, nil
+}
+
+func (me *Telemetry) ProofStart(ctx context.Context, backend string, nbConstraints int) context.Context {
+	ctx, _ = me.tracer.Start(ctx, "eonark.prove", trace.WithAttributes(
+		attribute.String("backend", backend),
+		attribute.Int("constraints", nbConstraints),
+	))
+	return context.WithValue(ctx, startedKey{}, started{at: time.Now(), backend: backend})
+}
+
+func (me *Telemetry) ProofEnd(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	start := startedOf(ctx)
+	status := "ok"
+	if err != nil {
+		status = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	me.proofs.WithLabelValues(start.backend, status).Inc()
+	me.proofSeconds.WithLabelValues(start.backend).Observe(time.Since(start.at).Seconds())
+	span.End()
+}
+
+func (me *Telemetry) RoundStart(ctx context.Context, round observer.Round, backend string) context.Context {
+	ctx, _ = me.tracer.Start(ctx, "eonark.round."+round.String(), trace.WithAttributes(
+		attribute.String("backend", backend),
+	))
+	return context.WithValue(ctx, startedKey{}, started{at: time.Now(), backend: backend})
+}
+
+func (me *Telemetry) RoundEnd(ctx context.Context, round observer.Round, err error) {
+	span := trace.SpanFromContext(ctx)
+	start := startedOf(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	me.roundSeconds.WithLabelValues(round.String(), start.backend).Observe(time.Since(start.at).Seconds())
+	span.End()
+}
+
+func (me *Telemetry) MSM(ctx context.Context, backend string, size int) {
+	me.msmSize.WithLabelValues(backend).Observe(float64(size))
+	trace.SpanFromContext(ctx).AddEvent("msm", trace.WithAttributes(
+		attribute.String("backend", backend),
+		attribute.Int("size", size),
+	))
+}
+
+func (me *Telemetry) NTT(ctx context.Context, backend string, size int) {
+	me.nttSize.WithLabelValues(backend).Observe(float64(size))
+	trace.SpanFromContext(ctx).AddEvent("ntt", trace.WithAttributes(
+		attribute.String("backend", backend),
+		attribute.Int("size", size),
+	))
+}
+
+func (me *Telemetry) Transfer(ctx context.Context, toDevice bool, bytes int) {
+	direction := "from_device"
+	if toDevice {
+		direction = "to_device"
+	}
+	me.deviceBytes.WithLabelValues(direction).Add(float64(bytes))
+	trace.SpanFromContext(ctx).AddEvent("transfer", trace.WithAttributes(
+		attribute.String("direction", direction),
+		attribute.Int("bytes", bytes),
+	))
+}
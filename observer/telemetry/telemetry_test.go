@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/eon-protocol/eonark/observer"
+)
+
+func TestTelemetry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	spans := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+	tel, err := New(reg, tracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(reg, tracer); err == nil {
+		t.Fatal("metrics registered twice")
+	}
+
+	proof := observer.Start(context.Background(), tel, observer.BACKEND_ICICLE, 1000)
+	proof.RoundStart(observer.ROUND_SOLVE)
+	proof.RoundEnd(observer.ROUND_SOLVE, nil)
+	// ending twice or ending a round never started reports nothing
+	proof.RoundEnd(observer.ROUND_SOLVE, nil)
+	proof.RoundEnd(observer.ROUND_Z, nil)
+	proof.RoundStart(observer.ROUND_LRO)
+	proof.MSM(observer.BACKEND_ICICLE, 1024)
+	proof.NTT(observer.BACKEND_CPU, 4096)
+	proof.Transfer(true, 300)
+	proof.Transfer(false, 200)
+	failed := errors.New("failed")
+	proof.RoundEnd(observer.ROUND_LRO, failed)
+	proof.End(failed)
+	observer.Start(context.Background(), tel, observer.BACKEND_CPU, 1000).End(nil)
+
+	for _, v := range []struct {
+		c        prometheus.Collector
+		expected float64
+	}{
+		{tel.proofs.WithLabelValues(observer.BACKEND_ICICLE, "error"), 1},
+		{tel.proofs.WithLabelValues(observer.BACKEND_CPU, "ok"), 1},
+		{tel.deviceBytes.WithLabelValues("to_device"), 300},
+		{tel.deviceBytes.WithLabelValues("from_device"), 200},
+	} {
+		if got := testutil.ToFloat64(v.c); got != v.expected {
+			t.Fatalf("got %v, expected %v", got, v.expected)
+		}
+	}
+	if n := testutil.CollectAndCount(tel.roundSeconds); n != 2 {
+		t.Fatalf("got %d round series", n)
+	}
+	if n := testutil.CollectAndCount(tel.msmSize); n != 1 {
+		t.Fatalf("got %d msm series", n)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 4 {
+		t.Fatalf("got %d spans", len(ended))
+	}
+	solve, lro, prove := ended[0], ended[1], ended[2]
+	if solve.Name() != "eonark.round.solve" || lro.Name() != "eonark.round.lro" || prove.Name() != "eonark.prove" {
+		t.Fatalf("got spans %s %s %s", solve.Name(), lro.Name(), prove.Name())
+	}
+	if solve.Parent().SpanID() != prove.SpanContext().SpanID() || lro.Parent().SpanID() != prove.SpanContext().SpanID() {
+		t.Fatal("rounds are not children of the proof")
+	}
+	// msm, ntt, two transfers and the error
+	if len(prove.Events()) != 5 {
+		t.Fatalf("got %d proof events", len(prove.Events()))
+	}
+	if lro.Status().Description != "failed" {
+		t.Fatalf("got lro status %v", lro.Status())
+	}
+}
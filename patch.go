@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"math/bits"
 	"runtime"
@@ -21,7 +20,6 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/iop"
-	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
 	"github.com/consensys/gnark/backend"
@@ -34,6 +32,10 @@ import (
 
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	"github.com/consensys/gnark/logger"
+
+	"github.com/eon-protocol/eonark/gpu"
+	"github.com/eon-protocol/eonark/observer"
+	"github.com/eon-protocol/eonark/zkcore"
 )
 
 const (
@@ -70,7 +72,7 @@ const (
 	order_blinding_Z = 2
 )
 
-func prove(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (*plonkbls12381.Proof, error) {
+func prove(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, trace *plonkbls12381.Trace, obs observer.ProverObserver, fullWitness witness.Witness, opts ...backend.ProverOption) (proof *plonkbls12381.Proof, err error) {
 	log := logger.Logger().With().
 		Str("curve", spr.CurveID().String()).
 		Int("nbConstraints", spr.GetNbConstraints()).
@@ -83,16 +85,21 @@ func prove(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, fullWitness witness
 	}
 
 	start := time.Now()
+	o := observer.Start(ctx, obs, observer.BACKEND_CPU, spr.GetNbConstraints())
+	defer func() { o.End(err) }()
 
 	// init instance
-	g, ctx := errgroup.WithContext(context.Background())
-	instance, err := newInstance(ctx, spr, pk, fullWitness, &opt)
+	g, gctx := errgroup.WithContext(ctx)
+	o.RoundStart(observer.ROUND_SETUP)
+	instance, err := newInstance(gctx, spr, pk, trace, fullWitness, &opt)
+	o.RoundEnd(observer.ROUND_SETUP, err)
 	if err != nil {
 		return nil, fmt.Errorf("new instance: %w", err)
 	}
+	instance.obs = o
 
 	// solve constraints
-	g.Go(instance.solveConstraints)
+	g.Go(o.Round(observer.ROUND_SOLVE, instance.solveConstraints))
 
 	// complete qk
 	g.Go(instance.completeQk)
@@ -101,24 +108,27 @@ func prove(spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, fullWitness witness
 	g.Go(instance.initBlindingPolynomials)
 
 	// derive gamma, beta (copy constraint)
-	g.Go(instance.deriveGammaAndBeta)
+	g.Go(o.Round(observer.ROUND_GAMMA_BETA, instance.deriveGammaAndBeta))
 
 	// compute accumulating ratio for the copy constraint
-	g.Go(instance.buildRatioCopyConstraint)
+	g.Go(o.Round(observer.ROUND_Z, instance.buildRatioCopyConstraint))
 
 	// compute h
-	g.Go(instance.computeQuotient)
+	g.Go(o.Round(observer.ROUND_QUOTIENT, instance.computeQuotient))
 
 	// open Z (blinded) at ωζ (proof.ZShiftedOpening)
 	g.Go(instance.openZ)
 
 	// linearized polynomial
-	g.Go(instance.computeLinearizedPolynomial)
+	g.Go(o.Round(observer.ROUND_LINEARIZATION, instance.computeLinearizedPolynomial))
 
 	// Batch opening
-	g.Go(instance.batchOpening)
+	g.Go(o.Round(observer.ROUND_OPENING, instance.batchOpening))
 
 	if err := g.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -135,7 +145,8 @@ type instance struct {
 	spr   *cs.SparseR1CS
 	opt   *backend.ProverConfig
 
-	fs *Transcript
+	fs  *zkcore.Transcript
+	obs *observer.Proof
 
 	// polynomials
 	x                         []*iop.Polynomial // x stores tracks the polynomial we need
@@ -174,7 +185,7 @@ type instance struct {
 	trace *plonkbls12381.Trace
 }
 
-func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, fullWitness witness.Witness, opts *backend.ProverConfig) (*instance, error) {
+func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.ProvingKey, trace *plonkbls12381.Trace, fullWitness witness.Witness, opts *backend.ProverConfig) (*instance, error) {
 	s := instance{
 		ctx:                    ctx,
 		pk:                     pk,
@@ -183,7 +194,7 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.Prov
 		opt:                    opts,
 		fullWitness:            fullWitness,
 		bp:                     make([]*iop.Polynomial, nb_blinding_polynomials),
-		fs:                     NewTranscript(CID_GAMMA, CID_BETA, CID_ALPHA, CID_ZETA),
+		fs:                     zkcore.NewTranscript(zkcore.POSEIDON2, CID_GAMMA, CID_BETA, CID_ALPHA, CID_ZETA),
 		chLRO:                  make(chan struct{}, 1),
 		chQk:                   make(chan struct{}, 1),
 		chbp:                   make(chan struct{}, 1),
@@ -217,8 +228,12 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *plonkbls12381.Prov
 		s.domain1 = fft.NewDomain(4*sizeSystem, fft.WithoutPrecompute())
 	}
 
-	// build trace
-	s.trace = plonkbls12381.NewTrace(spr, s.domain0)
+	// build trace, or reuse a precomputed one; the prover mutates it in place
+	if trace != nil && uint64(trace.Ql.Size()) == s.domain0.Cardinality {
+		s.trace = gpu.CloneTrace(trace)
+	} else {
+		s.trace = plonkbls12381.NewTrace(spr, s.domain0)
+	}
 
 	return &s, nil
 }
@@ -267,6 +282,7 @@ func (s *instance) bsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
 	if s.proof.Bsb22Commitments[commDepth], err = kzg.Commit(s.cCommitments[commDepth].Coefficients(), s.pk.KzgLagrange); err != nil {
 		return err
 	}
+	s.obs.MSM(observer.BACKEND_CPU, len(committedValues))
 	resval := HashCompress(PREFIX_BSB, HashG1(s.proof.Bsb22Commitments[commDepth]))
 	res.Set(&resval)
 	res.BigInt(outs[0])
@@ -277,6 +293,7 @@ func (s *instance) bsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
 // solveConstraints computes the evaluation of the polynomials L, R, O
 // and sets x[id_L], x[id_R], x[id_O] in Lagrange form
 func (s *instance) solveConstraints() error {
+	s.obs.RoundStart(observer.ROUND_SOLVE)
 	_solution, err := s.spr.Solve(s.fullWitness, s.opt.SolverOpts...)
 	if err != nil {
 		return err
@@ -299,9 +316,10 @@ func (s *instance) solveConstraints() error {
 	s.x[id_O] = iop.NewPolynomial(&evaluationODomainSmall, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
 
 	wg.Wait()
+	s.obs.RoundEnd(observer.ROUND_SOLVE, nil)
 
 	// commit to l, r, o and add blinding factors
-	if err := s.commitToLRO(); err != nil {
+	if err := s.obs.Round(observer.ROUND_LRO, s.commitToLRO)(); err != nil {
 		return err
 	}
 	close(s.chLRO)
@@ -351,6 +369,10 @@ func (s *instance) commitToLRO() error {
 		return errContextDone
 	case <-s.chbp:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.obs.RoundStart(observer.ROUND_LRO)
 
 	g := new(errgroup.Group)
 
@@ -379,7 +401,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return witness.ErrInvalidWitness
 	}
 
-	if err := Dev_bindPublicData(s.fs, CID_GAMMA, s.pk.Vk, wWitness[:len(s.spr.Public)]); err != nil {
+	if err := zkcore.BindVerifyingKey(s.fs, CID_GAMMA, s.pk.Vk); err != nil {
 		return err
 	}
 
@@ -389,6 +411,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return errContextDone
 	case <-s.chLRO:
 	}
+	s.obs.RoundStart(observer.ROUND_GAMMA_BETA)
 
 	if err := s.fs.Bind(CID_GAMMA, HashG1(s.proof.LRO[0])); err != nil {
 		return err
@@ -403,7 +426,7 @@ func (s *instance) deriveGammaAndBeta() error {
 		return err
 	}
 
-	gamma, err := Dev_deriveRandomness(s.fs, CID_GAMMA)
+	gamma, err := zkcore.DeriveRandomness(s.fs, CID_GAMMA)
 	if err != nil {
 		return err
 	}
@@ -427,6 +450,7 @@ func (s *instance) deriveGammaAndBeta() error {
 func (s *instance) commitToPolyAndBlinding(p, b *iop.Polynomial) (commit curve.G1Affine, err error) {
 
 	commit, err = kzg.Commit(p.Coefficients(), s.pk.KzgLagrange)
+	s.obs.MSM(observer.BACKEND_CPU, p.Size())
 
 	// we add in the blinding contribution
 	n := int(s.domain0.Cardinality)
@@ -442,12 +466,12 @@ func (s *instance) deriveAlpha() (err error) {
 		alphaDeps[i] = &s.proof.Bsb22Commitments[i]
 	}
 	alphaDeps[len(alphaDeps)-1] = &s.proof.Z
-	s.alpha, err = Dev_deriveRandomness(s.fs, CID_ALPHA, alphaDeps...)
+	s.alpha, err = zkcore.DeriveRandomness(s.fs, CID_ALPHA, alphaDeps...)
 	return err
 }
 
 func (s *instance) deriveZeta() (err error) {
-	s.zeta, err = Dev_deriveRandomness(s.fs, CID_ZETA, &s.proof.H[0], &s.proof.H[1], &s.proof.H[2])
+	s.zeta, err = zkcore.DeriveRandomness(s.fs, CID_ZETA, &s.proof.H[0], &s.proof.H[1], &s.proof.H[2])
 	return
 }
 
@@ -486,6 +510,10 @@ func (s *instance) computeQuotient() (err error) {
 		return errContextDone
 	case <-s.chZ:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.obs.RoundStart(observer.ROUND_QUOTIENT)
 
 	// derive alpha
 	if err = s.deriveAlpha(); err != nil {
@@ -507,11 +535,16 @@ func (s *instance) computeQuotient() (err error) {
 	if err != nil {
 		return err
 	}
+	s.obs.NTT(observer.BACKEND_CPU, int(s.domain1.Cardinality))
 
 	// commit to h
-	if err := commitToQuotient(s.h1(), s.h2(), s.h3(), s.proof, s.pk.Kzg); err != nil {
+	h1, h2, h3 := s.h1(), s.h2(), s.h3()
+	if err := commitToQuotient(h1, h2, h3, s.proof, s.pk.Kzg); err != nil {
 		return err
 	}
+	s.obs.MSM(observer.BACKEND_CPU, len(h1))
+	s.obs.MSM(observer.BACKEND_CPU, len(h2))
+	s.obs.MSM(observer.BACKEND_CPU, len(h3))
 
 	if err := s.deriveZeta(); err != nil {
 		return err
@@ -536,6 +569,10 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 		return errContextDone
 	case <-s.chGammaBeta:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.obs.RoundStart(observer.ROUND_Z)
 
 	// TODO @gbotrel having iop.BuildRatioCopyConstraint return something
 	// with capacity = len() + 4 would avoid extra alloc / copy during openZ
@@ -571,6 +608,9 @@ func (s *instance) openZ() (err error) {
 		return errContextDone
 	case <-s.chH:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
 	var zetaShifted fr.Element
 	zetaShifted.Mul(&s.zeta, &s.pk.Vk.Generator)
 	s.blindedZ = getBlindedCoefficients(s.x[id_Z], s.bp[id_Bz])
@@ -579,6 +619,7 @@ func (s *instance) openZ() (err error) {
 	if err != nil {
 		return err
 	}
+	s.obs.MSM(observer.BACKEND_CPU, len(s.blindedZ))
 	close(s.chZOpening)
 	return nil
 }
@@ -628,6 +669,10 @@ func (s *instance) computeLinearizedPolynomial() error {
 		return errContextDone
 	case <-s.chH:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.obs.RoundStart(observer.ROUND_LINEARIZATION)
 
 	qcpzeta := make([]fr.Element, len(s.commitmentInfo))
 	var blzeta, brzeta, bozeta fr.Element
@@ -686,6 +731,7 @@ func (s *instance) computeLinearizedPolynomial() error {
 	if err != nil {
 		return err
 	}
+	s.obs.MSM(observer.BACKEND_CPU, len(s.linearizedPolynomial))
 	close(s.chLinearizedPolynomial)
 	return nil
 }
@@ -698,6 +744,10 @@ func (s *instance) batchOpening() error {
 		return errContextDone
 	case <-s.chLinearizedPolynomial:
 	}
+	if s.ctx.Err() != nil {
+		return errContextDone
+	}
+	s.obs.RoundStart(observer.ROUND_OPENING)
 
 	polysQcp := coefficients(s.trace.Qcp)
 	polysToOpen := make([][]fr.Element, 6+len(polysQcp))
@@ -728,8 +778,16 @@ func (s *instance) batchOpening() error {
 		s.pk.Kzg,
 		s.proof.ZShiftedOpening.ClaimedValue,
 	)
+	if err != nil {
+		return err
+	}
+	size := 0
+	for _, p := range polysToOpen {
+		size = max(size, len(p))
+	}
+	s.obs.MSM(observer.BACKEND_CPU, size)
 
-	return err
+	return nil
 }
 
 // evaluate the full set of constraints, all polynomials in x are back in
@@ -890,6 +948,9 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 	bufBatchInvert := make([]fr.Element, s.domain0.Cardinality)
 
 	for i := 0; i < rho; i++ {
+		if s.ctx.Err() != nil {
+			return nil, errContextDone
+		}
 
 		coset.Mul(&coset, &shifters[i])
 		cosetExponentiatedToNMinusOne.Exp(coset, bn).
@@ -932,6 +993,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 			nbTasks := calculateNbTasks(len(s.x)-1) * 2
 			// shift polynomials to be in the correct coset
 			p.ToCanonical(s.domain0, nbTasks)
+			s.obs.NTT(observer.BACKEND_CPU, int(n))
 
 			// scale by shifter[i]
 			var w []fr.Element
@@ -950,6 +1012,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 			// fft in the correct coset
 			p.ToLagrange(s.domain0, nbTasks).ToRegular()
+			s.obs.NTT(observer.BACKEND_CPU, int(n))
 		})
 
 		wgBuf.Wait()
@@ -1000,6 +1063,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 			}
 			p.ToCanonical(s.domain0, 8).ToRegular()
 			scalePowers(p, cs)
+			s.obs.NTT(observer.BACKEND_CPU, int(n))
 		})
 
 		for _, q := range s.bp {
@@ -1304,6 +1368,7 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 	s3canonical := s.trace.S3.Coefficients()
 
 	s.trace.Qk.ToCanonical(s.domain0).ToRegular()
+	s.obs.NTT(observer.BACKEND_CPU, int(s.domain0.Cardinality))
 
 	// len(h1)=len(h2)=len(blindedZCanonical)=len(h3)+1 when Statistical ZK is activated
 	// len(h1)=len(h2)=len(h3)=len(blindedZCanonical)-1 when Statistical ZK is deactivated
@@ -1531,7 +1596,7 @@ func dividePolyByXminusA(f []fr.Element, fa, a fr.Element) []fr.Element {
 func deriveGamma(point fr.Element, digests []kzg.Digest, claimedValues []fr.Element, dataTranscript ...fr.Element) (fr.Element, error) {
 
 	// derive the challenge gamma, binded to the point and the commitments
-	fs := NewTranscript(CID_GAMMA)
+	fs := zkcore.NewTranscript(zkcore.POSEIDON2, CID_GAMMA)
 	if err := fs.Bind(CID_GAMMA, point); err != nil {
 		return fr.Element{}, err
 	}
@@ -1584,135 +1649,6 @@ func fold(di []kzg.Digest, fai []fr.Element, ci []fr.Element) (kzg.Digest, fr.El
 
 }
 
-var (
-	errChallengeNotFound            = errors.New("challenge not recorded in the transcript")
-	errChallengeAlreadyComputed     = errors.New("challenge already computed, cannot be binded to other values")
-	errPreviousChallengeNotComputed = errors.New("the previous challenge is needed and has not been computed")
-)
-
-// Transcript handles the creation of challenges for Fiat Shamir.
-type Transcript struct {
-	challenges map[fr.Element]challenge
-	previous   *challenge
-}
-
-type challenge struct {
-	position   int            // position of the challenge in the Transcript. order matters.
-	bindings   [][]fr.Element // bindings stores the variables a challenge is binded to.
-	value      fr.Element     // value stores the computed challenge
-	isComputed bool
-}
-
-// NewTranscript returns a new transcript.
-// h is the hash function that is used to compute the challenges.
-// challenges are the name of the challenges. The order of the challenges IDs matters.
-func NewTranscript(challengesID ...fr.Element) *Transcript {
-	challenges := make(map[fr.Element]challenge)
-	for i := range challengesID {
-		challenges[challengesID[i]] = challenge{position: i}
-	}
-	t := &Transcript{
-		challenges: challenges,
-	}
-	return t
-}
-
-// Bind binds the challenge to value. A challenge can be binded to an
-// arbitrary number of values, but the order in which the binded values
-// are added is important. Once a challenge is computed, it cannot be
-// binded to other values.
-func (t *Transcript) Bind(challengeID fr.Element, bValue ...fr.Element) error {
-
-	currentChallenge, ok := t.challenges[challengeID]
-	if !ok {
-		return errChallengeNotFound
-	}
-
-	if currentChallenge.isComputed {
-		return errChallengeAlreadyComputed
-	}
-
-	bCopy := make([]fr.Element, len(bValue))
-	copy(bCopy, bValue)
-	currentChallenge.bindings = append(currentChallenge.bindings, bCopy)
-	t.challenges[challengeID] = currentChallenge
-
-	return nil
-
-}
-
-// ComputeChallenge computes the challenge corresponding to the given name.
-// The challenge is:
-// * H(name || previous_challenge || binded_values...) if the challenge is not the first one
-// * H(name || binded_values... ) if it is the first challenge
-func (t *Transcript) ComputeChallenge(challengeID fr.Element) (fr.Element, error) {
-	challenge, ok := t.challenges[challengeID]
-	if !ok {
-		return fr.Element{}, errChallengeNotFound
-	}
-
-	// if the challenge was already computed we return it
-	if challenge.isComputed {
-		return challenge.value, nil
-	}
-
-	// reset before populating the internal state
-	resfrom := []fr.Element{}
-
-	resfrom = append(resfrom, challengeID)
-
-	// write the previous challenge if it's not the first challenge
-	if challenge.position != 0 {
-		if t.previous == nil || (t.previous.position != challenge.position-1) {
-			return fr.Element{}, errPreviousChallengeNotComputed
-		}
-		resfrom = append(resfrom, t.previous.value)
-	}
-
-	// write the binded values in the order they were added
-	for _, b := range challenge.bindings {
-		resfrom = append(resfrom, b...)
-	}
-
-	// compute the hash of the accumulated values
-	res := hashsum(resfrom...)
-
-	challenge.value = res
-	challenge.isComputed = true
-
-	t.challenges[challengeID] = challenge
-	t.previous = &challenge
-
-	return res, nil
-
-}
-
-const WIDTH = 2
-const ROuND_FULL = 8
-const ROUND_PARTIAL = 56
-
-var GetPermutation = sync.OnceValue(func() *poseidon2.Permutation {
-	return poseidon2.NewPermutationWithSeed(WIDTH, ROuND_FULL, ROUND_PARTIAL, "EON_POSEIDON2_HASH_SEED")
-})
-
-func compress(x, y fr.Element) fr.Element {
-	vars := [2]fr.Element{x, y}
-	if err := GetPermutation().Permutation(vars[:]); err != nil {
-		log.Fatalln(err)
-	}
-	var ret fr.Element
-	ret.Add(&vars[1], &y)
-	return ret
-}
-
-func hashsum(val ...fr.Element) fr.Element {
-	var ret fr.Element
-	for _, v := range val {
-		ret = compress(ret, v)
-	}
-	return ret
-}
-
 func parallelize(nbIterations int, work func(int, int), maxCpus ...int) {
 
 	nbTasks := runtime.NumCPU()
@@ -1749,54 +1685,3 @@ func parallelize(nbIterations int, work func(int, int), maxCpus ...int) {
 
 	wg.Wait()
 }
-func Dev_bindPublicData(fs *Transcript, challenge fr.Element, vk *plonkbls12381.VerifyingKey, publicInputs []fr.Element) error {
-
-	// permutation
-	if err := fs.Bind(challenge, HashG1(vk.S[0])); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.S[1])); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.S[2])); err != nil {
-		return err
-	}
-
-	// coefficients
-	if err := fs.Bind(challenge, HashG1(vk.Ql)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.Qr)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.Qm)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.Qo)); err != nil {
-		return err
-	}
-	if err := fs.Bind(challenge, HashG1(vk.Qk)); err != nil {
-		return err
-	}
-	for i := range vk.Qcp {
-		if err := fs.Bind(challenge, HashG1(vk.Qcp[i])); err != nil {
-			return err
-		}
-	}
-
-	return nil
-
-}
-
-func Dev_deriveRandomness(fs *Transcript, challenge fr.Element, points ...*curve.G1Affine) (fr.Element, error) {
-	for _, p := range points {
-		if err := fs.Bind(challenge, HashG1(*p)); err != nil {
-			return fr.Element{}, err
-		}
-	}
-	b, err := fs.ComputeChallenge(challenge)
-	if err != nil {
-		return fr.Element{}, err
-	}
-	return b, nil
-}
@@ -1,24 +1,33 @@
 package eonark
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"log"
+	"sync"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
-	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/plonk"
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 	"github.com/consensys/gnark/constraint"
 	csbls12381 "github.com/consensys/gnark/constraint/bls12-381"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
+
+	"github.com/eon-protocol/eonark/gpu"
+	"github.com/eon-protocol/eonark/srs"
 )
 
 type Pk struct {
 	vk  Vk
 	ccs csbls12381.SparseR1CS
+	src SRSSource
+
+	trace *plonkbls12381.Trace
+	mu    sync.Mutex
+	gpk   *gpu.ProvingKey
 }
 
 func (me *Pk) Compile(circuit frontend.Circuit) error {
@@ -26,35 +35,108 @@ func (me *Pk) Compile(circuit frontend.Circuit) error {
 	if err != nil {
 		return err
 	}
-	nc := len(ccs.GetCommitments().CommitmentIndexes())
-	if nc != 1 {
-		return fmt.Errorf("number of commitments is %d not 1", nc)
-	}
-	spkc, spkl, err := ReadProvingKey(plonk.SRSSize(ccs))
+	spkc, spkl, err := me.readProvingKey(plonk.SRSSize(ccs))
 	if err != nil {
 		return err
 	}
-	ipk, _, err := plonk.Setup(ccs, &kzg.SRS{Pk: spkc, Vk: SRS_VK}, &kzg.SRS{Pk: spkl, Vk: SRS_VK})
+	kvk := me.vk.kzgVk()
+	ipk, _, err := plonk.Setup(ccs, &kzg.SRS{Pk: spkc, Vk: kvk}, &kzg.SRS{Pk: spkl, Vk: kvk})
 	if err != nil {
 		return err
 	}
 	return me.FromGnarkConstraintSystemAndProvingKey(ccs, ipk)
 }
 
+func (me *Pk) SetSRSSource(src SRSSource) {
+	me.src = src
+}
+
+// SetTestSRS makes me compile and prove on the unsafe s instead of the SRS
+// source, see Vk.SetTestSRS.
+func (me *Pk) SetTestSRS(s *srs.SRS) {
+	me.vk.SetTestSRS(s)
+	me.trace = nil
+	me.Close()
+}
+
+// Precompute prepares pk for repeated proving: the trace is computed once
+// and, with icicle, the SRS is read now instead of on the first proof.
+func (me *Pk) Precompute() error {
+	me.Close()
+	size := uint64(me.ccs.GetNbConstraints() + len(me.ccs.Public))
+	me.trace = plonkbls12381.NewTrace(&me.ccs, fft.NewDomain(size))
+	if gpu.HasIcicle {
+		_, err := me.deviceKey()
+		return err
+	}
+	return nil
+}
+
+// deviceKey returns the key proving on devices, made on the first device
+// proof. It keeps the SRS on every device used until Close.
+func (me *Pk) deviceKey() (*gpu.ProvingKey, error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.gpk != nil {
+		return me.gpk, nil
+	}
+	pk, err := me.gnarkProvingKey()
+	if err != nil {
+		return nil, err
+	}
+	gpk, err := gpu.WrapProvingKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	gpk.Trace = me.trace
+	me.gpk = gpk
+	return gpk, nil
+}
+
+// Close frees the device memory kept since the first device proof.
+func (me *Pk) Close() error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.gpk == nil {
+		return nil
+	}
+	err := me.gpk.Close()
+	me.gpk = nil
+	return err
+}
+
+func (me *Pk) readProvingKey(sc, sl int) (kzg.ProvingKey, kzg.ProvingKey, error) {
+	if me.vk.testSRS != nil {
+		return me.vk.testSRS.ProvingKey(sc, sl)
+	}
+	if me.src == nil {
+		return SRS_CACHE.ProvingKey(SRS_SOURCE, sc, sl)
+	}
+	return SRS_CACHE.ProvingKey(me.src, sc, sl)
+}
+
 func (me *Pk) Vk() Vk {
 	return me.vk
 }
 
 func (me *Pk) ToGnarkProvingKey() plonk.ProvingKey {
-	spkc, spkl, err := ReadProvingKey(plonk.SRSSize(&me.ccs))
+	pk, err := me.gnarkProvingKey()
 	if err != nil {
 		log.Fatalln(err)
 	}
+	return pk
+}
+
+func (me *Pk) gnarkProvingKey() (*plonkbls12381.ProvingKey, error) {
+	spkc, spkl, err := me.readProvingKey(plonk.SRSSize(&me.ccs))
+	if err != nil {
+		return nil, err
+	}
 	return &plonkbls12381.ProvingKey{
 		Kzg:         spkc,
 		KzgLagrange: spkl,
 		Vk:          me.vk.ToGnarkVerifyingKey().(*plonkbls12381.VerifyingKey),
-	}
+	}, nil
 }
 
 func (me *Pk) ToGnarkConstraintSystem() constraint.ConstraintSystem {
@@ -62,39 +144,86 @@ func (me *Pk) ToGnarkConstraintSystem() constraint.ConstraintSystem {
 }
 
 func (me *Pk) FromGnarkConstraintSystemAndProvingKey(ccs constraint.ConstraintSystem, pk plonk.ProvingKey) error {
-	me.vk.FromGnarkVerifyingKey(pk.VerifyingKey().(*plonkbls12381.VerifyingKey))
+	if err := me.vk.FromGnarkVerifyingKey(pk.VerifyingKey().(*plonkbls12381.VerifyingKey)); err != nil {
+		return err
+	}
 	me.ccs = *ccs.(*csbls12381.SparseR1CS)
+	me.trace = nil
+	me.Close()
 	return nil
 }
 
 func (me *Pk) FromGnarkConstraintSystemAndVerifyingKey(ccs constraint.ConstraintSystem, vk plonk.VerifyingKey) error {
-	me.vk.FromGnarkVerifyingKey(vk)
+	if err := me.vk.FromGnarkVerifyingKey(vk); err != nil {
+		return err
+	}
 	me.ccs = *ccs.(*csbls12381.SparseR1CS)
+	me.trace = nil
+	me.Close()
 	return nil
 }
 
-func (me *Pk) Prove(assignment frontend.Circuit) ([4]fr.Element, []fr.Element, *Proof, error) {
+// Prove returns the public inputs, the rest of the witness and the proof.
+func (me *Pk) Prove(assignment frontend.Circuit, opts ...ProverOption) ([]fr.Element, []fr.Element, *Proof, error) {
+	return me.ProveContext(context.Background(), assignment, opts...)
+}
+
+// ProveContext is Prove stopping with ctx.Err() once ctx is done. The
+// proof is abandoned between two rounds, releasing its device.
+func (me *Pk) ProveContext(ctx context.Context, assignment frontend.Circuit, opts ...ProverOption) ([]fr.Element, []fr.Element, *Proof, error) {
+	cfg, err := NewProverConfig(opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	witness, err := frontend.NewWitness(assignment, FIELD)
 	if err != nil {
-		return [4]fr.Element{}, nil, nil, err
+		return nil, nil, nil, err
+	}
+	if cfg.Debug {
+		if err := me.checkWitness(witness); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 	// gp, err := plonk.Prove(&me.ccs, me.ToGnarkProvingKey(), witness, OPT_PROVER)
 	// gp, err := prove(&me.ccs, me.ToGnarkProvingKey().(*plonkbls12381.ProvingKey), witness, OPT_PROVER)
-	gp, err := Prove(&me.ccs, me.ToGnarkProvingKey().(*plonkbls12381.ProvingKey), witness, OPT_PROVER, backend.WithIcicleAcceleration())
+	var gp *plonkbls12381.Proof
+	if gpu.HasIcicle {
+		var dk *gpu.ProvingKey
+		if dk, err = me.deviceKey(); err == nil {
+			gpk := *dk
+			gpk.Device, gpk.Pool, gpk.Observer = cfg.Device, cfg.Pool, cfg.Observer
+			gp, err = proveOnDevice(ctx, &me.ccs, &gpk, cfg, witness, OPT_PROVER)
+		}
+	} else {
+		var pk *plonkbls12381.ProvingKey
+		if pk, err = me.gnarkProvingKey(); err == nil {
+			gp, err = proveWithConfig(ctx, &me.ccs, pk, me.trace, cfg, witness, OPT_PROVER)
+		}
+	}
 
 	if err != nil {
-		return [4]fr.Element{}, nil, nil, err
+		return nil, nil, nil, err
 	}
 	var proof Proof
 	if err := proof.FromGnarkProof(gp); err != nil {
-		return [4]fr.Element{}, nil, nil, err
+		return nil, nil, nil, err
 	}
 	vec := witness.Vector().(fr.Vector)
-	return [4]fr.Element{vec[0], vec[1], vec[2], vec[3]}, vec[4:], &proof, nil
+	return vec[:me.vk.NP:me.vk.NP], vec[me.vk.NP:], &proof, nil
 }
 
 func (me *Pk) WriteTo(w io.Writer) (int64, error) {
-	if n, err := me.vk.WriteTo(w); err != nil {
+	return writeContainer(w, KIND_PK, me.writeRawTo)
+}
+
+func (me *Pk) ReadFrom(r io.Reader) (int64, error) {
+	me.trace = nil
+	me.Close()
+	return readContainer(r, KIND_PK, me.readRawFrom)
+}
+
+func (me *Pk) writeRawTo(w io.Writer) (int64, error) {
+	if n, err := me.vk.writeRawTo(w); err != nil {
 		return n, err
 	} else {
 		m, err := me.ccs.WriteTo(w)
@@ -102,8 +231,8 @@ func (me *Pk) WriteTo(w io.Writer) (int64, error) {
 	}
 }
 
-func (me *Pk) ReadFrom(r io.Reader) (int64, error) {
-	if n, err := me.vk.ReadFrom(r); err != nil {
+func (me *Pk) readRawFrom(r io.Reader, version uint16) (int64, error) {
+	if n, err := me.vk.readRawFrom(r, version); err != nil {
 		return n, err
 	} else {
 		m, err := me.ccs.ReadFrom(r)
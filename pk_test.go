@@ -0,0 +1,215 @@
+package eonark_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/observer"
+)
+
+// cancelDuringSolve cancels the running proof from inside the solver, so that
+// the later rounds see a done context.
+var cancelDuringSolve context.CancelFunc
+
+func cancelHint(_ *big.Int, ins, outs []*big.Int) error {
+	if cancelDuringSolve != nil {
+		cancelDuringSolve()
+	}
+	outs[0].Set(ins[0])
+	return nil
+}
+
+type cancelCircuit struct {
+	X frontend.Variable `gnark:",public"`
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+	W frontend.Variable `gnark:",public"`
+}
+
+func (me *cancelCircuit) Define(api frontend.API) error {
+	out, err := api.Compiler().NewHint(cancelHint, 1, me.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(out[0], me.X)
+	api.AssertIsEqual(api.Mul(me.X, me.Y), me.Z)
+	_, err = api.(frontend.Committer).Commit(me.W)
+	return err
+}
+
+func TestProveContext(t *testing.T) {
+	solver.RegisterHint(cancelHint)
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&cancelCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	assignment := &cancelCircuit{X: 2, Y: 3, Z: 6, W: 1}
+
+	publics, _, proof, err := pk.ProveContext(context.Background(), assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err := pk.ProveContext(ctx, assignment); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancelDuringSolve = cancel
+	defer func() { cancelDuringSolve = nil }()
+	if _, _, _, err := pk.ProveContext(ctx, assignment); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+type recorder struct {
+	observer.Nop
+	mu       sync.Mutex
+	backends []string
+	started  [observer.NUM_ROUNDS]int
+	ended    [observer.NUM_ROUNDS]int
+	msms     int
+	err      error
+}
+
+func (me *recorder) ProofStart(ctx context.Context, backend string, _ int) context.Context {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.backends = append(me.backends, backend)
+	return ctx
+}
+
+func (me *recorder) ProofEnd(_ context.Context, err error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.err = err
+}
+
+func (me *recorder) RoundStart(ctx context.Context, round observer.Round, _ string) context.Context {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.started[round]++
+	return ctx
+}
+
+func (me *recorder) RoundEnd(_ context.Context, round observer.Round, _ error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.ended[round]++
+}
+
+func (me *recorder) MSM(context.Context, string, int) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.msms++
+}
+
+func TestProveObserver(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&cancelCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	var rec recorder
+	if _, _, _, err := pk.Prove(&cancelCircuit{X: 2, Y: 3, Z: 6, W: 1}, eonark.WithObserver(&rec)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.backends) != 1 || rec.backends[0] != observer.BACKEND_CPU || rec.err != nil {
+		t.Fatalf("got backends %v, error %v", rec.backends, rec.err)
+	}
+	for round := observer.ROUND_SETUP; round < observer.NUM_ROUNDS; round++ {
+		if rec.started[round] != 1 || rec.ended[round] != 1 {
+			t.Fatalf("round %s started %d and ended %d times", round, rec.started[round], rec.ended[round])
+		}
+	}
+	// bsb22, L, R, O, Z, 3 quotient shards, Z opening, linearized polynomial, batch opening
+	if rec.msms != 11 {
+		t.Fatalf("got %d MSMs", rec.msms)
+	}
+	if _, _, _, err := pk.Prove(&cancelCircuit{}, eonark.WithObserver(nil)); err == nil {
+		t.Fatal("nil observer accepted")
+	}
+}
+
+func TestCheckAssignment(t *testing.T) {
+	solver.RegisterHint(cancelHint)
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&cancelCircuit{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.CheckAssignment(&cancelCircuit{X: 2, Y: 3, Z: 6, W: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var cerr *eonark.ConstraintError
+	if err := pk.CheckAssignment(&cancelCircuit{X: 2, Y: 3, Z: 7, W: 1}); !errors.As(err, &cerr) {
+		t.Fatalf("got %v", err)
+	}
+	if cerr.Solved != [3]bool{true, true, true} {
+		t.Fatalf("got solved wires %v", cerr.Solved)
+	}
+	// the gate does not hold for the reported values
+	var sum, tmp fr.Element
+	sum.Mul(&cerr.QL, &cerr.L)
+	sum.Add(&sum, tmp.Mul(&cerr.QR, &cerr.R))
+	sum.Add(&sum, tmp.Mul(&cerr.QM, &cerr.L).Mul(&tmp, &cerr.R))
+	sum.Add(&sum, tmp.Mul(&cerr.QO, &cerr.O))
+	sum.Add(&sum, &cerr.QK)
+	if sum.IsZero() {
+		t.Fatalf("reported gate is satisfied: %v", cerr)
+	}
+	if _, _, _, err := pk.Prove(&cancelCircuit{X: 2, Y: 3, Z: 7, W: 1}, eonark.WithDebug()); !errors.As(err, &cerr) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestProveSRSError(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	// the SRS is read again for every proof, an unreadable one fails the proof
+	pk.SetTestSRS(nil)
+	pk.SetSRSSource(eonark.FileSRS(path.Join(t.TempDir(), "SRS.CK.BIN")))
+	if err := pk.Precompute(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	if _, _, _, err := pk.Prove(&publicsCircuit{Publics: []frontend.Variable{1, 2}, Sum: 3}); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+type privateCircuit struct {
+	X frontend.Variable
+}
+
+func (me *privateCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(me.X, me.X), 4)
+	return nil
+}
+
+func TestCompileNoPublics(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&privateCircuit{}); err == nil {
+		t.Fatal("circuit without public inputs compiled")
+	}
+}
@@ -1,8 +1,10 @@
 package eonark
 
 import (
+	"encoding/binary"
 	"errors"
 	"io"
+	"slices"
 
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
@@ -11,9 +13,13 @@ import (
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
 )
 
+// Proof is a proof with one BSB22 commitment BSB and its selector opening CQC
+// per commitment of the circuit, in the order of Vk.QC.
 type Proof struct {
-	CW1, CW2, CW3, CH1, CH2, CH3, CPZ, BSB, HBP, HZO bls12381.G1Affine
-	CZO, COL, CVL, CVR, CVO, CS1, CS2, CQC           fr.Element
+	CW1, CW2, CW3, CH1, CH2, CH3, CPZ, HBP, HZO bls12381.G1Affine
+	BSB                                         []bls12381.G1Affine
+	CZO, COL, CVL, CVR, CVO, CS1, CS2           fr.Element
+	CQC                                         []fr.Element
 }
 
 func (me *Proof) ToGnarkPRoof() plonk.Proof {
@@ -21,10 +27,10 @@ func (me *Proof) ToGnarkPRoof() plonk.Proof {
 		LRO:              [3]bls12381.G1Affine{me.CW1, me.CW2, me.CW3},
 		Z:                me.CPZ,
 		H:                [3]bls12381.G1Affine{me.CH1, me.CH2, me.CH3},
-		Bsb22Commitments: []bls12381.G1Affine{me.BSB},
+		Bsb22Commitments: slices.Clone(me.BSB),
 		BatchedProof: kzg.BatchOpeningProof{
 			H:             me.HBP,
-			ClaimedValues: []fr.Element{me.COL, me.CVL, me.CVR, me.CVO, me.CS1, me.CS2, me.CQC},
+			ClaimedValues: append([]fr.Element{me.COL, me.CVL, me.CVR, me.CVO, me.CS1, me.CS2}, me.CQC...),
 		},
 		ZShiftedOpening: kzg.OpeningProof{
 			H:            me.HZO,
@@ -34,7 +40,7 @@ func (me *Proof) ToGnarkPRoof() plonk.Proof {
 }
 func (me *Proof) FromGnarkProof(proof plonk.Proof) error {
 	gp := proof.(*plonkbls12381.Proof)
-	if len(gp.Bsb22Commitments) != 1 || len(gp.BatchedProof.ClaimedValues) != 7 {
+	if len(gp.BatchedProof.ClaimedValues) != 6+len(gp.Bsb22Commitments) {
 		return errors.New("invalid number of commitments")
 	}
 	me.CW1 = gp.LRO[0]
@@ -44,7 +50,7 @@ func (me *Proof) FromGnarkProof(proof plonk.Proof) error {
 	me.CH2 = gp.H[1]
 	me.CH3 = gp.H[2]
 	me.CPZ = gp.Z
-	me.BSB = gp.Bsb22Commitments[0]
+	me.BSB = slices.Clone(gp.Bsb22Commitments)
 	me.HBP = gp.BatchedProof.H
 	me.HZO = gp.ZShiftedOpening.H
 	me.CZO = gp.ZShiftedOpening.ClaimedValue
@@ -54,122 +60,88 @@ func (me *Proof) FromGnarkProof(proof plonk.Proof) error {
 	me.CVO = gp.BatchedProof.ClaimedValues[3]
 	me.CS1 = gp.BatchedProof.ClaimedValues[4]
 	me.CS2 = gp.BatchedProof.ClaimedValues[5]
-	me.CQC = gp.BatchedProof.ClaimedValues[6]
+	me.CQC = slices.Clone(gp.BatchedProof.ClaimedValues[6:])
 	return nil
 }
+
+// Equal reports whether me and other are the same proof.
+func (me *Proof) Equal(other *Proof) bool {
+	return me.CW1 == other.CW1 && me.CW2 == other.CW2 && me.CW3 == other.CW3 && me.CH1 == other.CH1 && me.CH2 == other.CH2 && me.CH3 == other.CH3 &&
+		me.CPZ == other.CPZ && me.HBP == other.HBP && me.HZO == other.HZO && slices.Equal(me.BSB, other.BSB) &&
+		me.CZO == other.CZO && me.COL == other.COL && me.CVL == other.CVL && me.CVR == other.CVR && me.CVO == other.CVO &&
+		me.CS1 == other.CS1 && me.CS2 == other.CS2 && slices.Equal(me.CQC, other.CQC)
+}
+
 func (me *Proof) WriteTo(w io.Writer) (int64, error) {
-	enc := bls12381.NewEncoder(w)
-	if err := enc.Encode(&me.CW1); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CW2); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CW3); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CH1); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CH2); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CH3); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CPZ); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.BSB); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.HBP); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.HZO); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CZO); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.COL); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CVL); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CVR); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CVO); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CS1); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CS2); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.CQC); err != nil {
-		return enc.BytesWritten(), err
-	}
-	return enc.BytesWritten(), nil
+	return writeContainer(w, KIND_PROOF, me.writeRawTo)
 }
+
 func (me *Proof) ReadFrom(r io.Reader) (int64, error) {
-	dec := bls12381.NewDecoder(r)
-	if err := dec.Decode(&me.CW1); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CW2); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CW3); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CH1); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CH2); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CH3); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CPZ); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.BSB); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.HBP); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.HZO); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CZO); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.COL); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CVL); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CVR); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CVO); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CS1); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CS2); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.CQC); err != nil {
-		return dec.BytesRead(), err
+	return readContainer(r, KIND_PROOF, me.readRawFrom)
+}
+
+func (me *Proof) writeRawTo(w io.Writer) (int64, error) {
+	if len(me.BSB) != len(me.CQC) {
+		return 0, errors.New("invalid number of commitments")
 	}
-	return dec.BytesRead(), nil
+	enc := bls12381.NewEncoder(w)
+	for _, p := range []any{&me.CW1, &me.CW2, &me.CW3, &me.CH1, &me.CH2, &me.CH3, &me.CPZ, &me.HBP, &me.HZO, &me.CZO, &me.COL, &me.CVL, &me.CVR, &me.CVO, &me.CS1, &me.CS2} {
+		if err := enc.Encode(p); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	n, err := w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(me.BSB))))
+	if err != nil {
+		return int64(n) + enc.BytesWritten(), err
+	}
+	for i := range me.BSB {
+		if err := enc.Encode(&me.BSB[i]); err != nil {
+			return int64(n) + enc.BytesWritten(), err
+		}
+		if err := enc.Encode(&me.CQC[i]); err != nil {
+			return int64(n) + enc.BytesWritten(), err
+		}
+	}
+	return int64(n) + enc.BytesWritten(), nil
+}
+
+// readRawFrom reads the layout of version: before 3 a proof has exactly one
+// commitment, BSB after CPZ and CQC last.
+func (me *Proof) readRawFrom(r io.Reader, version uint16) (int64, error) {
+	dec := bls12381.NewDecoder(r)
+	if version < 3 {
+		me.BSB = make([]bls12381.G1Affine, 1)
+		me.CQC = make([]fr.Element, 1)
+		for _, p := range []any{&me.CW1, &me.CW2, &me.CW3, &me.CH1, &me.CH2, &me.CH3, &me.CPZ, &me.BSB[0], &me.HBP, &me.HZO, &me.CZO, &me.COL, &me.CVL, &me.CVR, &me.CVO, &me.CS1, &me.CS2, &me.CQC[0]} {
+			if err := dec.Decode(p); err != nil {
+				return dec.BytesRead(), err
+			}
+		}
+		return dec.BytesRead(), nil
+	}
+	for _, p := range []any{&me.CW1, &me.CW2, &me.CW3, &me.CH1, &me.CH2, &me.CH3, &me.CPZ, &me.HBP, &me.HZO, &me.CZO, &me.COL, &me.CVL, &me.CVR, &me.CVO, &me.CS1, &me.CS2} {
+		if err := dec.Decode(p); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	buf := [4]byte{}
+	n, err := io.ReadFull(r, buf[:])
+	if err != nil {
+		return int64(n) + dec.BytesRead(), err
+	}
+	// grown one by one, a forged count fails on EOF instead of allocating
+	me.BSB, me.CQC = nil, nil
+	for range binary.BigEndian.Uint32(buf[:]) {
+		var bsb bls12381.G1Affine
+		var cqc fr.Element
+		if err := dec.Decode(&bsb); err != nil {
+			return int64(n) + dec.BytesRead(), err
+		}
+		if err := dec.Decode(&cqc); err != nil {
+			return int64(n) + dec.BytesRead(), err
+		}
+		me.BSB = append(me.BSB, bsb)
+		me.CQC = append(me.CQC, cqc)
+	}
+	return int64(n) + dec.BytesRead(), nil
 }
@@ -0,0 +1,100 @@
+package eonark
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/debug"
+
+	"github.com/eon-protocol/eonark/gpu"
+	"github.com/eon-protocol/eonark/observer"
+)
+
+// ProverConfig holds the eonark specific prover settings, the gnark ones
+// are always OPT_PROVER.
+type ProverConfig struct {
+	Device   gpu.Device
+	Pool     *gpu.DevicePool
+	Report   *ProveReport
+	Observer observer.ProverObserver
+	Debug    bool
+}
+
+const PROVER_CPU = "cpu"
+const PROVER_ICICLE = "icicle"
+
+// ProveReport tells which prover produced a proof. Fallback is the device
+// error that made an icicle proof be redone on CPU.
+type ProveReport struct {
+	Prover   string
+	Fallback error
+}
+
+type ProverOption func(*ProverConfig) error
+
+func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
+	ret := ProverConfig{Debug: debug.Debug}
+	for _, opt := range opts {
+		if err := opt(&ret); err != nil {
+			return ProverConfig{}, err
+		}
+	}
+	return ret, nil
+}
+
+// WithDevice proves on device when eonark is built with icicle.
+func WithDevice(device gpu.Device) ProverOption {
+	return func(cfg *ProverConfig) error {
+		if device.Type == "" || device.Id < 0 {
+			return errors.New("invalid device")
+		}
+		cfg.Device = device
+		return nil
+	}
+}
+
+// WithDevicePool proves on the least loaded device of pool when eonark is
+// built with icicle.
+func WithDevicePool(pool *gpu.DevicePool) ProverOption {
+	return func(cfg *ProverConfig) error {
+		if pool == nil {
+			return errors.New("nil device pool")
+		}
+		cfg.Pool = pool
+		return nil
+	}
+}
+
+// WithReport fills report once the proof is done.
+func WithReport(report *ProveReport) ProverOption {
+	return func(cfg *ProverConfig) error {
+		cfg.Report = report
+		return nil
+	}
+}
+
+// WithObserver reports the rounds, MSMs, NTTs and device transfers of the
+// proof to o, see observer/telemetry for a Prometheus and OpenTelemetry one.
+func WithObserver(o observer.ProverObserver) ProverOption {
+	return func(cfg *ProverConfig) error {
+		if o == nil {
+			return errors.New("nil prover observer")
+		}
+		cfg.Observer = o
+		return nil
+	}
+}
+
+// WithDebug checks the assignment with Pk.CheckAssignment before proving, it
+// is the default when built with gnark's debug tag.
+func WithDebug() ProverOption {
+	return func(cfg *ProverConfig) error {
+		cfg.Debug = true
+		return nil
+	}
+}
+
+func (me *ProverConfig) report(prover string, fallback error) {
+	if me.Report != nil {
+		*me.Report = ProveReport{Prover: prover, Fallback: fallback}
+	}
+}
@@ -0,0 +1,153 @@
+package eonark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// Circuit is a circuit registered by name with RegisterCircuit, usually in
+// the init of its package, so that tools compile and prove it by name.
+type Circuit struct {
+	// Name is set by RegisterCircuit.
+	Name string
+	// New returns the circuit to compile, its slices sized.
+	New func() frontend.Circuit
+	// Decode returns the assignment of a JSON object, DecodeAssignment when
+	// nil.
+	Decode func(data []byte) (frontend.Circuit, error)
+	// Metadata describes the circuit to its users, e.g. a "description".
+	Metadata map[string]string
+}
+
+var circuits = struct {
+	sync.RWMutex
+	m map[string]*Circuit
+}{m: make(map[string]*Circuit)}
+
+// RegisterCircuit makes the circuit available by name. It panics if New is
+// nil or the name is already registered.
+func RegisterCircuit(name string, circuit Circuit) {
+	circuits.Lock()
+	defer circuits.Unlock()
+	if circuit.New == nil {
+		panic("eonark: RegisterCircuit of " + name + " without New")
+	}
+	if _, dup := circuits.m[name]; dup {
+		panic("eonark: RegisterCircuit called twice for " + name)
+	}
+	circuit.Name = name
+	circuits.m[name] = &circuit
+}
+
+// Circuits returns the sorted names of the registered circuits.
+func Circuits() []string {
+	circuits.RLock()
+	defer circuits.RUnlock()
+	names := make([]string, 0, len(circuits.m))
+	for name := range circuits.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CircuitByName returns the circuit registered as name.
+func CircuitByName(name string) (*Circuit, error) {
+	circuits.RLock()
+	circuit, ok := circuits.m[name]
+	circuits.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown circuit %q, one of: %s", name, strings.Join(Circuits(), ", "))
+	}
+	return circuit, nil
+}
+
+// DecodeAssignment decodes the assignment of data with Decode.
+func (me *Circuit) DecodeAssignment(data []byte) (frontend.Circuit, error) {
+	if me.Decode != nil {
+		return me.Decode(data)
+	}
+	return DecodeAssignment(me.New(), data)
+}
+
+// DecodeAssignment assigns the variables of circuit from a JSON object
+// following its gnark schema: one key per field, named by its gnark tag,
+// holding a number or string for a variable, a list for a slice or array and
+// an object for a struct. All the variables must be given.
+func DecodeAssignment(circuit frontend.Circuit, data []byte) (frontend.Circuit, error) {
+	tVariable := reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+	s, err := schema.New(FIELD, circuit, tVariable)
+	if err != nil {
+		return nil, err
+	}
+	tValue := reflect.TypeOf((*fr.Element)(nil))
+	instance := s.Instantiate(tValue)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(instance); err != nil {
+		return nil, err
+	}
+	// both walks visit the variables in the order of the schema
+	var values []*fr.Element
+	var names []string
+	if _, err := schema.Walk(FIELD, instance, tValue, func(field schema.LeafInfo, v reflect.Value) error {
+		values = append(values, v.Interface().(*fr.Element))
+		names = append(names, field.FullName())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	i := 0
+	if _, err := schema.Walk(FIELD, circuit, tVariable, func(field schema.LeafInfo, v reflect.Value) error {
+		if i >= len(values) {
+			return errors.New("assignment does not follow the circuit schema")
+		}
+		if values[i] == nil {
+			return fmt.Errorf("missing assignment for %s", names[i])
+		}
+		v.Set(reflect.ValueOf(*values[i]))
+		i++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return circuit, nil
+}
+
+// LoadCircuit is LoadOrCompile of the circuit registered as name, saved under
+// its name.
+func (me *KeyStore) LoadCircuit(name string) (*Pk, error) {
+	circuit, err := CircuitByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return me.LoadOrCompile(name, circuit.New())
+}
+
+// CircuitByAddress returns the registered circuit of the key of address and
+// its proving key. Only the keys saved by LoadCircuit are found: nothing is
+// compiled.
+func (me *KeyStore) CircuitByAddress(address fr.Element) (*Circuit, *Pk, error) {
+	for _, name := range Circuits() {
+		if alias, err := me.alias(name); err != nil || alias != address {
+			continue
+		}
+		pk, err := me.Load(address)
+		if err != nil {
+			continue
+		}
+		circuit, err := CircuitByName(name)
+		return circuit, pk, err
+	}
+	return nil, nil, fmt.Errorf("no circuit loaded by LoadCircuit has the address %s", address.Text(16))
+}
@@ -0,0 +1,107 @@
+package eonark_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
+
+type registryPoint struct {
+	X, Y frontend.Variable
+}
+
+type registryCircuit struct {
+	Sum    frontend.Variable `gnark:",public"`
+	Terms  []frontend.Variable
+	Point  registryPoint `gnark:"P"`
+	Ignore int           `gnark:"-"`
+}
+
+func (me *registryCircuit) Define(api frontend.API) error {
+	sum := api.Add(me.Point.X, me.Point.Y)
+	for _, v := range me.Terms {
+		sum = api.Add(sum, v)
+	}
+	api.AssertIsEqual(sum, me.Sum)
+	return nil
+}
+
+func init() {
+	eonark.RegisterCircuit("registry-test", eonark.Circuit{
+		New: func() frontend.Circuit {
+			return &registryCircuit{Terms: make([]frontend.Variable, 3)}
+		},
+	})
+}
+
+func TestCircuitRegistry(t *testing.T) {
+	if !slices.Contains(eonark.Circuits(), "registry-test") || !slices.Contains(eonark.Circuits(), "permissionless") {
+		t.Fatalf("circuits not registered: %v", eonark.Circuits())
+	}
+	circuit, err := eonark.CircuitByName("registry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eonark.CircuitByName("unknown"); err == nil {
+		t.Fatal("unknown circuit found")
+	}
+	assignment, err := circuit.DecodeAssignment([]byte(`{"Sum":"21","Terms":[1,"2","0x3"],"P":{"X":7,"Y":"8"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{
+		`{"Sum":"21","Terms":[1,2,3],"P":{"X":7}}`,
+		`{"Sum":"21","Terms":[1,2,3],"P":{"X":7,"Y":8},"Z":1}`,
+		`{"Sum":"21","Terms":[1,2],"P":{"X":7,"Y":8}}`,
+	} {
+		if _, err := circuit.DecodeAssignment([]byte(data)); err == nil {
+			t.Fatalf("assignment %s decoded", data)
+		}
+	}
+
+	store := eonark.KeyStore{Dir: t.TempDir(), Source: pinTestSRS(t, 6)}
+	// the unreadable alias of another circuit is skipped
+	if _, err := store.LoadCircuit("permissionless"); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := filepath.Glob(path.Join(store.Dir, "*.ADDR"))
+	if err != nil || len(aliases) != 1 {
+		t.Fatalf("%d key aliases saved: %v", len(aliases), err)
+	}
+	if err := os.WriteFile(aliases[0], []byte("not an address"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pk, err := store.LoadCircuit("registry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	publics, _, proof, err := pk.Prove(assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+	found, loaded, err := store.CircuitByAddress(vk.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lvk := loaded.Vk(); found.Name != "registry-test" || lvk.Address() != vk.Address() {
+		t.Fatalf("address of registry-test found as %s", found.Name)
+	}
+	// the keys not loaded by LoadCircuit are not compiled to be found
+	empty := eonark.KeyStore{Dir: t.TempDir(), Source: store.Source}
+	if _, _, err := empty.CircuitByAddress(vk.Address()); err == nil {
+		t.Fatal("address of registry-test found in an empty store")
+	}
+	if files, err := os.ReadDir(empty.Dir); err != nil || len(files) != 0 {
+		t.Fatalf("lookup saved %d files: %v", len(files), err)
+	}
+}
@@ -0,0 +1,515 @@
+package eonark
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"text/template"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+
+	"github.com/eon-protocol/eonark/zkcore"
+)
+
+const SOLIDITY_PROOF_SIZE = 10*128 + 8*32
+
+// MarshalSolidity encodes the proof as expected by the contract produced by
+// Vk.ExportSolidity: the ten G1 points in EIP-2537 layout followed by the
+// eight claimed values as 32-byte big-endian words, in struct field order.
+// The contract only verifies circuits with one commitment, other proofs
+// encode to nil.
+func (me *Proof) MarshalSolidity() []byte {
+	if len(me.BSB) != 1 || len(me.CQC) != 1 {
+		return nil
+	}
+	ret := make([]byte, 0, SOLIDITY_PROOF_SIZE)
+	for _, v := range []*bls12381.G1Affine{&me.CW1, &me.CW2, &me.CW3, &me.CH1, &me.CH2, &me.CH3, &me.CPZ, &me.BSB[0], &me.HBP, &me.HZO} {
+		ret = appendEIP2537Fp(ret, &v.X)
+		ret = appendEIP2537Fp(ret, &v.Y)
+	}
+	for _, v := range []*fr.Element{&me.CZO, &me.COL, &me.CVL, &me.CVR, &me.CVO, &me.CS1, &me.CS2, &me.CQC[0]} {
+		b := v.Bytes()
+		ret = append(ret, b[:]...)
+	}
+	return ret
+}
+
+func (me *Vk) ExportSolidity(w io.Writer) error {
+	if me.testSRS != nil {
+		return errors.New("key of a test SRS")
+	}
+	if len(me.QC) != 1 || len(me.CI) != 1 {
+		return fmt.Errorf("number of commitments is %d not 1", len(me.QC))
+	}
+	generator, err := fr.Generator(1 << me.SZ)
+	if err != nil {
+		return err
+	}
+	var sizeinv, omegaci, shift2, p256 fr.Element
+	sizeinv.SetUint64(1 << me.SZ).Inverse(&sizeinv)
+	omegaci.Exp(generator, big.NewInt(int64(me.NP)+int64(me.CI[0])))
+	shift2.Square(&COSET_SHIFT)
+	var two256, rinv256 big.Int
+	two256.Lsh(big.NewInt(1), 256)
+	p256.SetBigInt(&two256)
+	rinv256.ModInverse(fr.Modulus(), &two256)
+
+	params := zkcore.Parameters()
+	var rounds []solidityRound
+	for i, rk := range params.RoundKeys {
+		round := solidityRound{Full: i < HASH_RF/2 || i >= HASH_RF/2+HASH_RP}
+		for _, k := range rk {
+			round.Keys = append(round.Keys, solidityFr(k))
+		}
+		rounds = append(rounds, round)
+	}
+
+	var zero fr.Element
+	data := solidityData{
+		R:           solidityInt(fr.Modulus()),
+		P256ModR:    solidityFr(p256),
+		RInv256:     solidityInt(&rinv256),
+		NbPublic:    int(me.NP),
+		LogSize:     me.SZ,
+		SizeInv:     solidityFr(sizeinv),
+		Omega:       solidityFr(generator),
+		OmegaCI:     solidityFr(omegaci),
+		CosetShift:  solidityFr(COSET_SHIFT),
+		CosetShift2: solidityFr(shift2),
+		GammaPrefix: solidityFr(HashSum(CID_GAMMA, HashG1(me.S1), HashG1(me.S2), HashG1(me.S3), HashG1(me.QL), HashG1(me.QR), HashG1(me.QM), HashG1(me.QO), HashG1(me.QK), HashG1(me.QC[0]), fr.NewElement(uint64(me.NP)))),
+		BetaPrefix:  solidityFr(HashCompress(zero, CID_BETA)),
+		AlphaPrefix: solidityFr(HashCompress(zero, CID_ALPHA)),
+		ZetaPrefix:  solidityFr(HashCompress(zero, CID_ZETA)),
+		FoldPrefix:  solidityFr(HashCompress(zero, CID_GAMMA)),
+		PrefixBSB:   solidityFr(PREFIX_BSB),
+		HashS1:      solidityFr(HashG1(me.S1)),
+		HashS2:      solidityFr(HashG1(me.S2)),
+		HashQC:      solidityFr(HashG1(me.QC[0])),
+		ProofSize:   SOLIDITY_PROOF_SIZE,
+		G2Generator: solidityG2(&SRS_VK.G2[0]),
+		G2Tau:       solidityG2(&SRS_VK.G2[1]),
+		Rounds:      rounds,
+		Points: []solidityPoint{
+			solidityG1("G1", &SRS_VK.G1),
+			solidityG1("QL", &me.QL),
+			solidityG1("QR", &me.QR),
+			solidityG1("QM", &me.QM),
+			solidityG1("QO", &me.QO),
+			solidityG1("QK", &me.QK),
+			solidityG1("S1", &me.S1),
+			solidityG1("S2", &me.S2),
+			solidityG1("S3", &me.S3),
+			solidityG1("QC", &me.QC[0]),
+		},
+	}
+	return solidityTemplate.Execute(w, data)
+}
+
+type solidityData struct {
+	R, P256ModR, RInv256                                         string
+	NbPublic                                                     int
+	LogSize                                                      uint8
+	SizeInv, Omega, OmegaCI                                      string
+	CosetShift, CosetShift2                                      string
+	GammaPrefix, BetaPrefix, AlphaPrefix, ZetaPrefix, FoldPrefix string
+	PrefixBSB, HashS1, HashS2, HashQC                            string
+	ProofSize                                                    int
+	G2Generator, G2Tau                                           string
+	Rounds                                                       []solidityRound
+	Points                                                       []solidityPoint
+}
+
+type solidityRound struct {
+	Full bool
+	Keys []string
+}
+
+type solidityPoint struct {
+	Name           string
+	XH, XL, YH, YL string
+}
+
+func solidityInt(val *big.Int) string {
+	return fmt.Sprintf("0x%064x", val)
+}
+
+func solidityFr(val fr.Element) string {
+	b := val.Bytes()
+	return "0x" + hex.EncodeToString(b[:])
+}
+
+func solidityG1(name string, val *bls12381.G1Affine) solidityPoint {
+	x, y := val.X.Bytes(), val.Y.Bytes()
+	return solidityPoint{
+		Name: name,
+		XH:   "0x" + hex.EncodeToString(x[:16]),
+		XL:   "0x" + hex.EncodeToString(x[16:]),
+		YH:   "0x" + hex.EncodeToString(y[:16]),
+		YL:   "0x" + hex.EncodeToString(y[16:]),
+	}
+}
+
+func solidityG2(val *bls12381.G2Affine) string {
+	var ret []byte
+	for _, v := range []*fp.Element{&val.X.A0, &val.X.A1, &val.Y.A0, &val.Y.A1} {
+		ret = appendEIP2537Fp(ret, v)
+	}
+	return hex.EncodeToString(ret)
+}
+
+func appendEIP2537Fp(dst []byte, val *fp.Element) []byte {
+	b := val.Bytes()
+	dst = append(dst, make([]byte, 64-fp.Bytes)...)
+	return append(dst, b[:]...)
+}
+
+var solidityTemplate = template.Must(template.New("verifier").Parse(`// SPDX-License-Identifier: MIT
+// Code generated by eonark. DO NOT EDIT.
+
+pragma solidity ^0.8.24;
+
+/// PLONK verifier over BLS12-381 with the eonark Poseidon2 transcript.
+/// Requires the EIP-2537 precompiles. Proofs are encoded with Proof.MarshalSolidity.
+contract Verifier {
+    uint256 internal constant R = {{.R}};
+    uint256 internal constant P256_MOD_R = {{.P256ModR}};
+    uint256 internal constant R_INV_256 = {{.RInv256}};
+
+    uint256 internal constant NB_PUBLIC = {{.NbPublic}};
+    uint256 internal constant LOG_SIZE = {{.LogSize}};
+    uint256 internal constant SIZE_INV = {{.SizeInv}};
+    uint256 internal constant OMEGA = {{.Omega}};
+    uint256 internal constant OMEGA_CI = {{.OmegaCI}};
+    uint256 internal constant COSET_SHIFT = {{.CosetShift}};
+    uint256 internal constant COSET_SHIFT_2 = {{.CosetShift2}};
+
+    uint256 internal constant GAMMA_PREFIX = {{.GammaPrefix}};
+    uint256 internal constant BETA_PREFIX = {{.BetaPrefix}};
+    uint256 internal constant ALPHA_PREFIX = {{.AlphaPrefix}};
+    uint256 internal constant ZETA_PREFIX = {{.ZetaPrefix}};
+    uint256 internal constant FOLD_PREFIX = {{.FoldPrefix}};
+    uint256 internal constant PREFIX_BSB = {{.PrefixBSB}};
+    uint256 internal constant HASH_S1 = {{.HashS1}};
+    uint256 internal constant HASH_S2 = {{.HashS2}};
+    uint256 internal constant HASH_QC = {{.HashQC}};
+{{range .Points}}
+    uint256 internal constant {{.Name}}_XH = {{.XH}};
+    uint256 internal constant {{.Name}}_XL = {{.XL}};
+    uint256 internal constant {{.Name}}_YH = {{.YH}};
+    uint256 internal constant {{.Name}}_YL = {{.YL}};
+{{- end}}
+
+    bytes internal constant G2_GENERATOR = hex"{{.G2Generator}}";
+    bytes internal constant G2_TAU = hex"{{.G2Tau}}";
+
+    uint256 internal constant PROOF_SIZE = {{.ProofSize}};
+    uint256 internal constant CW1 = 0;
+    uint256 internal constant CW2 = 128;
+    uint256 internal constant CW3 = 256;
+    uint256 internal constant CH1 = 384;
+    uint256 internal constant CH2 = 512;
+    uint256 internal constant CH3 = 640;
+    uint256 internal constant CPZ = 768;
+    uint256 internal constant BSB = 896;
+    uint256 internal constant HBP = 1024;
+    uint256 internal constant HZO = 1152;
+    uint256 internal constant CZO = 1280;
+    uint256 internal constant COL = 1312;
+    uint256 internal constant CVL = 1344;
+    uint256 internal constant CVR = 1376;
+    uint256 internal constant CVO = 1408;
+    uint256 internal constant CS1 = 1440;
+    uint256 internal constant CS2 = 1472;
+    uint256 internal constant CQC = 1504;
+
+    address internal constant MODEXP = address(0x05);
+    address internal constant G1MSM = address(0x0c);
+    address internal constant PAIRING = address(0x0f);
+
+    struct State {
+        uint256 gamma;
+        uint256 beta;
+        uint256 alpha;
+        uint256 zeta;
+        uint256 hcw1;
+        uint256 hcw2;
+        uint256 hcw3;
+        uint256 hbsb;
+        uint256 zh;
+        uint256 zn2;
+        uint256 alpha2l0;
+        uint256 pi;
+        uint256 s1;
+        uint256 cz;
+        uint256[4] lpd;
+        uint256 g;
+        uint256 eval;
+        uint256 lambda;
+    }
+
+    function verify(bytes calldata proof, uint256[NB_PUBLIC] calldata publics) external view returns (bool) {
+        if (proof.length != PROOF_SIZE) {
+            return false;
+        }
+        for (uint256 off = CZO; off < PROOF_SIZE; off += 32) {
+            if (_word(proof, off) >= R) {
+                return false;
+            }
+        }
+        for (uint256 i = 0; i < NB_PUBLIC; i++) {
+            if (publics[i] >= R) {
+                return false;
+            }
+        }
+        State memory s;
+        _challenges(s, proof, publics);
+        _publicInputs(s, publics);
+        if (!_linearised(s, proof)) {
+            return false;
+        }
+        bool ok;
+        (ok, s.lpd) = _linearisedDigest(s, proof);
+        if (!ok) {
+            return false;
+        }
+        return _batchOpening(s, proof, publics);
+    }
+
+    function _challenges(State memory s, bytes calldata proof, uint256[NB_PUBLIC] calldata publics) internal pure {
+        s.hcw1 = _hashPoint(proof, CW1);
+        s.hcw2 = _hashPoint(proof, CW2);
+        s.hcw3 = _hashPoint(proof, CW3);
+        s.hbsb = _hashPoint(proof, BSB);
+        uint256 h = _compress(GAMMA_PREFIX, s.hcw1);
+        h = _compress(h, s.hcw2);
+        h = _compress(h, s.hcw3);
+        for (uint256 i = 0; i < NB_PUBLIC; i++) {
+            h = _compress(h, publics[i]);
+        }
+        s.gamma = h;
+        s.beta = _compress(BETA_PREFIX, s.gamma);
+        h = _compress(ALPHA_PREFIX, s.beta);
+        h = _compress(h, s.hbsb);
+        s.alpha = _compress(h, _hashPoint(proof, CPZ));
+        h = _compress(ZETA_PREFIX, s.alpha);
+        h = _compress(h, _hashPoint(proof, CH1));
+        h = _compress(h, _hashPoint(proof, CH2));
+        s.zeta = _compress(h, _hashPoint(proof, CH3));
+    }
+
+    function _publicInputs(State memory s, uint256[NB_PUBLIC] calldata publics) internal view {
+        uint256 zn = s.zeta;
+        for (uint256 i = 0; i < LOG_SIZE; i++) {
+            zn = mulmod(zn, zn, R);
+        }
+        s.zh = addmod(zn, R - 1, R); // ζⁿ-1
+        s.zn2 = mulmod(zn, mulmod(s.zeta, s.zeta, R), R); // ζⁿ⁺²
+        uint256 zhn = mulmod(s.zh, SIZE_INV, R); // (ζⁿ-1)/n
+        uint256 l0 = mulmod(_inverse(addmod(s.zeta, R - 1, R)), zhn, R); // (ζⁿ-1)/(n(ζ-1))
+        s.alpha2l0 = mulmod(mulmod(l0, s.alpha, R), s.alpha, R); // α²*L₁(ζ)
+        uint256 pi = mulmod(mulmod(zhn, OMEGA_CI, R), _inverse(addmod(s.zeta, R - OMEGA_CI, R)), R);
+        pi = mulmod(pi, _compress(PREFIX_BSB, s.hbsb), R);
+        uint256 w = 1;
+        for (uint256 i = 0; i < NB_PUBLIC; i++) {
+            uint256 t = mulmod(mulmod(_inverse(addmod(s.zeta, R - w, R)), zhn, R), w, R);
+            pi = addmod(pi, mulmod(t, publics[i], R), R);
+            w = mulmod(w, OMEGA, R);
+        }
+        s.pi = pi; // PI(ζ)
+    }
+
+    function _linearised(State memory s, bytes calldata proof) internal pure returns (bool) {
+        uint256 l = addmod(addmod(mulmod(s.beta, _word(proof, CS1), R), s.gamma, R), _word(proof, CVL), R); // l(ζ)+β*s1(ζ)+γ
+        uint256 r = addmod(addmod(mulmod(s.beta, _word(proof, CS2), R), s.gamma, R), _word(proof, CVR), R); // r(ζ)+β*s2(ζ)+γ
+        uint256 lr = mulmod(mulmod(mulmod(l, r, R), s.alpha, R), _word(proof, CZO), R); // α*(l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*z(ωζ)
+        s.s1 = mulmod(lr, s.beta, R);
+        uint256 lin = mulmod(lr, addmod(_word(proof, CVO), s.gamma, R), R);
+        lin = addmod(addmod(lin, R - s.alpha2l0, R), s.pi, R);
+        uint256 bz = mulmod(s.beta, s.zeta, R);
+        uint256 t = mulmod(
+            addmod(addmod(bz, s.gamma, R), _word(proof, CVL), R),
+            addmod(addmod(mulmod(bz, COSET_SHIFT, R), s.gamma, R), _word(proof, CVR), R),
+            R
+        );
+        t = mulmod(t, addmod(addmod(mulmod(bz, COSET_SHIFT_2, R), _word(proof, CVO), R), s.gamma, R), R);
+        t = mulmod(t, s.alpha, R); // α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
+        s.cz = addmod(s.alpha2l0, R - t, R);
+        return addmod(lin, _word(proof, COL), R) == 0;
+    }
+
+    function _linearisedDigest(State memory s, bytes calldata proof) internal view returns (bool, uint256[4] memory) {
+        bytes memory input = new bytes(11 * 160);
+        _putProof(input, 0, proof, BSB, _word(proof, CQC));
+        _put(input, 1, QL_XH, QL_XL, QL_YH, QL_YL, _word(proof, CVL));
+        _put(input, 2, QR_XH, QR_XL, QR_YH, QR_YL, _word(proof, CVR));
+        _put(input, 3, QM_XH, QM_XL, QM_YH, QM_YL, mulmod(_word(proof, CVL), _word(proof, CVR), R));
+        _put(input, 4, QO_XH, QO_XL, QO_YH, QO_YL, _word(proof, CVO));
+        _put(input, 5, QK_XH, QK_XL, QK_YH, QK_YL, 1);
+        _put(input, 6, S3_XH, S3_XL, S3_YH, S3_YL, s.s1);
+        _putProof(input, 7, proof, CPZ, s.cz);
+        _putProof(input, 8, proof, CH1, (R - s.zh) % R);
+        uint256 t = mulmod(s.zn2, s.zh, R);
+        _putProof(input, 9, proof, CH2, (R - t) % R);
+        _putProof(input, 10, proof, CH3, (R - mulmod(t, s.zn2, R)) % R);
+        return _msm(input);
+    }
+
+    function _batchOpening(State memory s, bytes calldata proof, uint256[NB_PUBLIC] calldata publics) internal view returns (bool) {
+        uint256 g = _compress(FOLD_PREFIX, s.zeta);
+        g = _compress(g, _hashG1(s.lpd[0], s.lpd[1], s.lpd[2], s.lpd[3]));
+        g = _compress(g, s.hcw1);
+        g = _compress(g, s.hcw2);
+        g = _compress(g, s.hcw3);
+        g = _compress(g, HASH_S1);
+        g = _compress(g, HASH_S2);
+        g = _compress(g, HASH_QC);
+        for (uint256 off = COL; off < PROOF_SIZE; off += 32) {
+            g = _compress(g, _word(proof, off));
+        }
+        g = _compress(g, _word(proof, CZO));
+
+        s.g = g;
+        s.lambda = uint256(keccak256(abi.encodePacked(proof, publics))) % R;
+        uint256 gi = 1;
+        for (uint256 off = COL; off < PROOF_SIZE; off += 32) {
+            s.eval = addmod(s.eval, mulmod(_word(proof, off), gi, R), R);
+            gi = mulmod(gi, g, R);
+        }
+        return _pairing(s, proof);
+    }
+
+    function _pairing(State memory s, bytes calldata proof) internal view returns (bool) {
+        (bool ok, uint256[4] memory lhs) = _pairingLhs(s, proof);
+        if (!ok) {
+            return false;
+        }
+        bytes memory input = new bytes(2 * 160);
+        _putProof(input, 0, proof, HBP, R - 1);
+        _putProof(input, 1, proof, HZO, (R - s.lambda) % R);
+        uint256[4] memory rhs;
+        (ok, rhs) = _msm(input);
+        if (!ok) {
+            return false;
+        }
+        bytes memory out;
+        (ok, out) = PAIRING.staticcall(abi.encodePacked(lhs, G2_GENERATOR, rhs, G2_TAU));
+        return ok && out.length == 32 && abi.decode(out, (uint256)) == 1;
+    }
+
+    function _pairingLhs(State memory s, bytes calldata proof) internal view returns (bool, uint256[4] memory) {
+        bytes memory input = new bytes(11 * 160);
+        _put(input, 0, s.lpd[0], s.lpd[1], s.lpd[2], s.lpd[3], 1);
+        {
+            uint256 g = s.g;
+            uint256 gi = g;
+            _putProof(input, 1, proof, CW1, gi);
+            gi = mulmod(gi, g, R);
+            _putProof(input, 2, proof, CW2, gi);
+            gi = mulmod(gi, g, R);
+            _putProof(input, 3, proof, CW3, gi);
+            gi = mulmod(gi, g, R);
+            _put(input, 4, S1_XH, S1_XL, S1_YH, S1_YL, gi);
+            gi = mulmod(gi, g, R);
+            _put(input, 5, S2_XH, S2_XL, S2_YH, S2_YL, gi);
+            gi = mulmod(gi, g, R);
+            _put(input, 6, QC_XH, QC_XL, QC_YH, QC_YL, gi);
+        }
+        uint256 eval = addmod(s.eval, mulmod(s.lambda, _word(proof, CZO), R), R);
+        _put(input, 7, G1_XH, G1_XL, G1_YH, G1_YL, (R - eval) % R);
+        _putProof(input, 8, proof, HBP, s.zeta);
+        _putProof(input, 9, proof, CPZ, s.lambda);
+        _putProof(input, 10, proof, HZO, mulmod(s.lambda, mulmod(s.zeta, OMEGA, R), R));
+        return _msm(input);
+    }
+
+    function _word(bytes calldata proof, uint256 off) internal pure returns (uint256) {
+        return uint256(bytes32(proof[off:off + 32]));
+    }
+
+    function _put(bytes memory input, uint256 i, uint256 xh, uint256 xl, uint256 yh, uint256 yl, uint256 scalar) internal pure {
+        assembly {
+            let p := add(add(input, 32), mul(i, 160))
+            mstore(p, xh)
+            mstore(add(p, 32), xl)
+            mstore(add(p, 64), yh)
+            mstore(add(p, 96), yl)
+            mstore(add(p, 128), scalar)
+        }
+    }
+
+    function _putProof(bytes memory input, uint256 i, bytes calldata proof, uint256 off, uint256 scalar) internal pure {
+        assembly {
+            let p := add(add(input, 32), mul(i, 160))
+            calldatacopy(p, add(proof.offset, off), 128)
+            mstore(add(p, 128), scalar)
+        }
+    }
+
+    function _msm(bytes memory input) internal view returns (bool ok, uint256[4] memory p) {
+        bytes memory out;
+        (ok, out) = G1MSM.staticcall(input);
+        if (!ok || out.length != 128) {
+            return (false, p);
+        }
+        p = abi.decode(out, (uint256[4]));
+    }
+
+    function _inverse(uint256 x) internal view returns (uint256) {
+        (bool ok, bytes memory out) = MODEXP.staticcall(abi.encode(32, 32, 32, x, R - 2, R));
+        require(ok && out.length == 32, "modexp failed");
+        return abi.decode(out, (uint256));
+    }
+
+    function _hashPoint(bytes calldata proof, uint256 off) internal pure returns (uint256) {
+        return _hashG1(_word(proof, off), _word(proof, off + 32), _word(proof, off + 64), _word(proof, off + 96));
+    }
+
+    function _hashG1(uint256 xh, uint256 xl, uint256 yh, uint256 yl) internal pure returns (uint256) {
+        (uint256 xq, uint256 xm) = _decompose(xh, xl);
+        (uint256 yq, uint256 ym) = _decompose(yh, yl);
+        return _compress(_compress(xq, xm), _compress(yq, ym));
+    }
+
+    // splits a base field coordinate hi*2²⁵⁶+lo into quotient and remainder by R;
+    // the division is exact once the remainder is removed, so it can be done mod 2²⁵⁶
+    function _decompose(uint256 hi, uint256 lo) internal pure returns (uint256 q, uint256 m) {
+        m = addmod(mulmod(hi, P256_MOD_R, R), lo % R, R);
+        unchecked {
+            q = (lo - m) * R_INV_256;
+        }
+    }
+
+    function _sbox(uint256 x) internal pure returns (uint256) {
+        uint256 x2 = mulmod(x, x, R);
+        return mulmod(mulmod(x2, x2, R), x, R);
+    }
+
+    // Poseidon2 with t=2 in compression mode: permute (x, y) and feed y forward
+    function _compress(uint256 x, uint256 y) internal pure returns (uint256) {
+        uint256 a = x;
+        uint256 b = y;
+        uint256 t = addmod(a, b, R);
+        a = addmod(t, a, R);
+        b = addmod(t, b, R);
+{{- range .Rounds}}
+{{- if .Full}}
+        a = _sbox(addmod(a, {{index .Keys 0}}, R));
+        b = _sbox(addmod(b, {{index .Keys 1}}, R));
+        t = addmod(a, b, R);
+        a = addmod(t, a, R);
+        b = addmod(t, b, R);
+{{- else}}
+        a = _sbox(addmod(a, {{index .Keys 0}}, R));
+        t = addmod(a, b, R);
+        a = addmod(a, t, R);
+        b = addmod(addmod(b, b, R), t, R);
+{{- end}}
+{{- end}}
+        return addmod(b, y, R);
+    }
+}
+`))
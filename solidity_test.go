@@ -0,0 +1,199 @@
+package eonark_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"math"
+	"math/big"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/accounts/permissionless"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var update = flag.Bool("update", false, "compile the verifier of TestExportSolidity with solc and write it to testdata")
+
+// VERIFIER_GOLDEN is the verifier of TestExportSolidity compiled by solc, so
+// that the contract runs in the EVM without solc. Its source is checked by
+// hash: a change of the exported Solidity needs -update.
+const VERIFIER_GOLDEN = "testdata/Verifier.json"
+
+type verifierGolden struct {
+	Source   string
+	ABI      json.RawMessage
+	Bytecode string
+}
+
+func verifierCode(t *testing.T, source string) (abi.ABI, []byte) {
+	sum := sha256.Sum256([]byte(source))
+	var golden verifierGolden
+	if *update {
+		golden.Source = hex.EncodeToString(sum[:])
+		golden.ABI, golden.Bytecode = compileSolidity(t, source)
+		data, err := json.MarshalIndent(golden, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(VERIFIER_GOLDEN, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(VERIFIER_GOLDEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &golden); err != nil {
+		t.Fatal(err)
+	}
+	if golden.Source != hex.EncodeToString(sum[:]) {
+		t.Fatalf("%s is not of the exported verifier, run go test -run TestExportSolidity -update with solc", VERIFIER_GOLDEN)
+	}
+	if _, err := exec.LookPath("solc"); err == nil && !*update {
+		golden.ABI, golden.Bytecode = compileSolidity(t, source)
+	}
+	parsed, err := abi.JSON(bytes.NewReader(golden.ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := hex.DecodeString(golden.Bytecode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed, code
+}
+
+func compileSolidity(t *testing.T, source string) (json.RawMessage, string) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Fatal("solc not found in PATH")
+	}
+	input, err := json.Marshal(map[string]any{
+		"language": "Solidity",
+		"sources":  map[string]any{"Verifier.sol": map[string]any{"content": source}},
+		"settings": map[string]any{
+			"optimizer":       map[string]any{"enabled": true, "runs": 200},
+			"evmVersion":      "prague",
+			"outputSelection": map[string]any{"*": map[string]any{"*": []string{"abi", "evm.bytecode.object"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(solc, "--standard-json")
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Errors []struct {
+			Severity         string
+			FormattedMessage string
+		}
+		Contracts map[string]map[string]struct {
+			ABI json.RawMessage
+			EVM struct {
+				Bytecode struct {
+					Object string
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			t.Fatal(e.FormattedMessage)
+		}
+	}
+	contract := output.Contracts["Verifier.sol"]["Verifier"]
+	return contract.ABI, contract.EVM.Bytecode.Object
+}
+
+func TestExportSolidity(t *testing.T) {
+	src := pinTestSRS(t, 10)
+	var pk eonark.Pk
+	pk.SetSRSSource(src)
+	if err := pk.Compile(&permissionless.Account{}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	var source strings.Builder
+	if err := vk.ExportSolidity(&source); err != nil {
+		t.Fatal(err)
+	}
+	verifier, code := verifierCode(t, source.String())
+
+	cfg := runtime.Config{
+		ChainConfig: params.MergedTestChainConfig,
+		Random:      &common.Hash{},
+		GasLimit:    math.MaxInt64,
+	}
+	_, addr, _, err := runtime.Create(code, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(proof *eonark.Proof, publics []fr.Element) bool {
+		var pub [eonark.NUM_PUBLIC]*big.Int
+		for i := range publics {
+			pub[i] = publics[i].BigInt(new(big.Int))
+		}
+		input, err := verifier.Pack("verify", proof.MarshalSolidity(), pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.GasLimit = 30_000_000
+		ret, left, err := runtime.Call(addr, input, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("gas used:", cfg.GasLimit-left)
+		out, err := verifier.Unpack("verify", ret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out[0].(bool)
+	}
+
+	for i := 0; i < 2; i++ {
+		publics, _, proof, err := pk.Prove(&permissionless.Account{X: i, Y: 2, Z: 3, W: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+		if !call(proof, publics) {
+			t.Fatal("valid proof rejected by the contract")
+		}
+
+		tampered := slices.Clone(publics)
+		tampered[3].SetUint64(5)
+		if vk.Verify(proof, tampered) == nil || call(proof, tampered) {
+			t.Fatal("proof accepted for wrong public inputs")
+		}
+		wrong := *proof
+		wrong.CZO.SetUint64(1)
+		if vk.Verify(&wrong, publics) == nil || call(&wrong, publics) {
+			t.Fatal("proof accepted with wrong claimed value")
+		}
+		wrong = *proof
+		wrong.HZO = proof.HBP
+		if vk.Verify(&wrong, publics) == nil || call(&wrong, publics) {
+			t.Fatal("proof accepted with wrong opening")
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package eonark
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+const SRS_CHUNK_POINTS = 1 << 16
+
+type SRSFile interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+type SRSSource interface {
+	OpenCK() (SRSFile, error)
+}
+
+type FileSRS string
+
+func (me FileSRS) OpenCK() (SRSFile, error) {
+	return openSRSFile(string(me))
+}
+
+type MemorySRS []byte
+
+func (me MemorySRS) OpenCK() (SRSFile, error) {
+	return memSRSFile{bytes.NewReader(me)}, nil
+}
+
+type memSRSFile struct {
+	*bytes.Reader
+}
+
+func (memSRSFile) Close() error {
+	return nil
+}
+
+type HTTPSRS struct {
+	Mirrors []string
+	Cache   string
+	Timeout time.Duration
+	Retries int
+}
+
+func (me HTTPSRS) OpenCK() (SRSFile, error) {
+	cache := me.Cache
+	if cache == "" {
+		cache = path.Join(DATA_CACHE_DIR, "SRS.CK.BIN")
+	}
+	// the points read from the cache are checked by their reader
+	if file, err := openSRSFile(cache); err == nil {
+		return file, nil
+	}
+	log.Println("local srsck cache not found; downloading ...")
+	if len(me.Mirrors) == 0 {
+		return nil, errors.New("no srs mirrors")
+	}
+	part := cache + ".part"
+	retries := max(me.Retries, 1)
+	var errs []error
+	for _, url := range me.Mirrors {
+		var err error
+		for i := 0; i < retries; i++ {
+			if err = me.download(url, part); err == nil {
+				break
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+		if err != nil {
+			// keep the partial file so the next mirror can resume it
+			continue
+		}
+		file, err := openSRSFile(part)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = CheckSRSCK(file)
+		file.Close()
+		if err != nil {
+			// a mirror served bad bytes; do not resume on top of them
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			os.Remove(part)
+			continue
+		}
+		if err := os.Rename(part, cache); err != nil {
+			return nil, err
+		}
+		return openSRSFile(cache)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (me HTTPSRS) download(url, part string) error {
+	file, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	client := http.Client{Timeout: me.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// server ignored the range; start over
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// already complete
+		return nil
+	default:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	bar := progressbar.DefaultBytes(total, "Downloading SRSCK")
+	bar.Add64(offset)
+	_, err = io.Copy(io.MultiWriter(file, bar), resp.Body)
+	return err
+}
+
+var SRS_SOURCE SRSSource = HTTPSRS{
+	Mirrors: []string{SRS_DOWNLOAD_URL},
+	Cache:   path.Join(DATA_CACHE_DIR, "SRS.CK.BIN"),
+	Timeout: time.Hour,
+	Retries: 3,
+}
+
+func ParseSRSSource(s string) (SRSSource, error) {
+	switch {
+	case s == "":
+		return SRS_SOURCE, nil
+	case strings.HasPrefix(s, "http://"), strings.HasPrefix(s, "https://"):
+		return HTTPSRS{
+			Mirrors: strings.Split(s, ","),
+			Cache:   path.Join(DATA_CACHE_DIR, "SRS.CK.BIN"),
+			Timeout: time.Hour,
+			Retries: 3,
+		}, nil
+	default:
+		if _, err := os.Stat(s); err != nil {
+			return nil, err
+		}
+		return FileSRS(s), nil
+	}
+}
+
+// CheckSRSCK hashes the whole file against SRS_CK_HASH and, on success,
+// records per-chunk hashes so later loads can verify just the prefix they use.
+func CheckSRSCK(file SRSFile) error {
+	sum, chunks, err := hashSRSChunks(file, sha256.New())
+	if err != nil {
+		return err
+	}
+	if sumstr := hex.EncodeToString(sum); sumstr != SRS_CK_HASH {
+		return fmt.Errorf("srsck sha256 mismatch: %s != %s", sumstr, SRS_CK_HASH)
+	}
+	idx := encodeSRSIndex(file.Size(), chunks)
+	if SRS_CK_IDX_HASH == "" {
+		// without a pinned index every load hashes the whole file
+		return nil
+	}
+	if err := checkSRSIndex(idx); err != nil {
+		return err
+	}
+	if err := os.WriteFile(srsIndexPath(), idx, 0o644); err != nil {
+		log.Println("cannot write srsck index:", err)
+	}
+	return nil
+}
+
+// CheckSRSCKPrefix verifies the chunks covering the first n points against the
+// chunk index, falling back to a full CheckSRSCK when no index matching
+// SRS_CK_IDX_HASH is available.
+func CheckSRSCKPrefix(file SRSFile, n int) error {
+	idx, err := os.ReadFile(srsIndexPath())
+	if err == nil {
+		err = checkSRSIndex(idx)
+	}
+	if err != nil {
+		return CheckSRSCK(file)
+	}
+	size, chunks, err := decodeSRSIndex(idx)
+	if err != nil {
+		return err
+	}
+	if size != file.Size() {
+		return fmt.Errorf("srsck size mismatch: %d != %d", file.Size(), size)
+	}
+	if int64(n)*96 > size {
+		return fmt.Errorf("srsck has %d points, %d required", size/96, n)
+	}
+	nchunks := (n + SRS_CHUNK_POINTS - 1) / SRS_CHUNK_POINTS
+	g := new(errgroup.Group)
+	g.SetLimit(runtime.NumCPU())
+	for i := 0; i < nchunks; i++ {
+		g.Go(func() error {
+			off := int64(i) * SRS_CHUNK_POINTS * 96
+			hasher := sha256.New()
+			if _, err := io.Copy(hasher, io.NewSectionReader(file, off, min(SRS_CHUNK_POINTS*96, size-off))); err != nil {
+				return err
+			}
+			if !bytes.Equal(hasher.Sum(nil), chunks[i]) {
+				return fmt.Errorf("srsck chunk %d sha256 mismatch", i)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func hashSRSChunks(file io.ReaderAt, full hash.Hash) ([]byte, [][]byte, error) {
+	var chunks [][]byte
+	buf := make([]byte, SRS_CHUNK_POINTS*96)
+	for off := int64(0); ; off += int64(len(buf)) {
+		n, err := file.ReadAt(buf, off)
+		if n > 0 {
+			full.Write(buf[:n])
+			sum := sha256.Sum256(buf[:n])
+			chunks = append(chunks, sum[:])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return full.Sum(nil), chunks, nil
+}
+
+func srsIndexPath() string {
+	return path.Join(DATA_CACHE_DIR, fmt.Sprintf("SRS.CK.%s.IDX", SRS_CK_HASH))
+}
+
+func encodeSRSIndex(size int64, chunks [][]byte) []byte {
+	idx := binary.BigEndian.AppendUint64(nil, uint64(size))
+	for _, c := range chunks {
+		idx = append(idx, c...)
+	}
+	return idx
+}
+
+func decodeSRSIndex(idx []byte) (int64, [][]byte, error) {
+	if len(idx) < 8 || (len(idx)-8)%sha256.Size != 0 {
+		return 0, nil, errors.New("invalid srsck index")
+	}
+	size := int64(binary.BigEndian.Uint64(idx))
+	var chunks [][]byte
+	for i := 8; i < len(idx); i += sha256.Size {
+		chunks = append(chunks, idx[i:i+sha256.Size])
+	}
+	if int64(len(chunks)) != (size+SRS_CHUNK_POINTS*96-1)/(SRS_CHUNK_POINTS*96) {
+		return 0, nil, errors.New("invalid srsck index")
+	}
+	return size, chunks, nil
+}
+
+func checkSRSIndex(idx []byte) error {
+	if SRS_CK_IDX_HASH == "" {
+		return errors.New("srsck index sha256 not pinned")
+	}
+	sum := sha256.Sum256(idx)
+	if sumstr := hex.EncodeToString(sum[:]); sumstr != SRS_CK_IDX_HASH {
+		return fmt.Errorf("srsck index sha256 mismatch: %s != %s", sumstr, SRS_CK_IDX_HASH)
+	}
+	return nil
+}
+
+func parseSRS(file io.ReaderAt, size int) ([]bls12381.G1Affine, error) {
+	val := make([]bls12381.G1Affine, size)
+	g := new(errgroup.Group)
+	g.SetLimit(runtime.NumCPU())
+	for start := 0; start < size; start += SRS_CHUNK_POINTS {
+		g.Go(func() error {
+			end := min(start+SRS_CHUNK_POINTS, size)
+			buf := make([]byte, (end-start)*96)
+			if _, err := file.ReadAt(buf, int64(start)*96); err != nil {
+				return err
+			}
+			for i := start; i < end; i++ {
+				b := buf[(i-start)*96:]
+				val[i].X.SetBytes(b[:48])
+				val[i].Y.SetBytes(b[48:96])
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
@@ -0,0 +1,87 @@
+// Package srs generates SRS in memory for tests. Their toxic waste is known,
+// so proofs of keys built on them can be forged: they are refused wherever a
+// key leaves the process.
+package srs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sync"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+)
+
+// SRS is an unsafe SRS of toxic waste Tau: CK holds [Tau^i]G1 and Vk is the
+// matching KZG verifying key.
+type SRS struct {
+	Tau *big.Int
+	CK  []bls12381.G1Affine
+	Vk  kzg.VerifyingKey
+
+	mu sync.Mutex
+	lk map[int][]bls12381.G1Affine
+}
+
+// NewTestSRS returns the SRS of size points for tau. The same size and tau
+// give the same SRS.
+func NewTestSRS(size uint64, tau *big.Int) (*SRS, error) {
+	if size < 2 {
+		return nil, errors.New("srs size should be at least 2")
+	}
+	if tau == nil || tau.Sign() <= 0 {
+		return nil, errors.New("srs tau should be positive")
+	}
+	s, err := kzg.NewSRS(size, new(big.Int).Set(tau))
+	if err != nil {
+		return nil, err
+	}
+	return &SRS{Tau: new(big.Int).Set(tau), CK: s.Pk.G1, Vk: s.Vk, lk: make(map[int][]bls12381.G1Affine)}, nil
+}
+
+// ProvingKey returns the first sc points and the Lagrange form of the first
+// sl points, as eonark.ReadProvingKey. The Lagrange forms are kept.
+func (me *SRS) ProvingKey(sc, sl int) (ck kzg.ProvingKey, lk kzg.ProvingKey, err error) {
+	if sc > len(me.CK) || sl > len(me.CK) {
+		return ck, lk, fmt.Errorf("test srs has %d points, %d needed", len(me.CK), max(sc, sl))
+	}
+	if bits.OnesCount(uint(sl)) != 1 {
+		return ck, lk, errors.New("invalid sl")
+	}
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.lk[sl] == nil {
+		if me.lk[sl], err = kzg.ToLagrangeG1(me.CK[:sl]); err != nil {
+			return
+		}
+	}
+	ck.G1, lk.G1 = me.CK[:sc], me.lk[sl]
+	return
+}
+
+// TEST_TAU is the toxic waste of the SRS of ForTests.
+var TEST_TAU = big.NewInt(42)
+
+var shared = struct {
+	sync.Mutex
+	m map[int]*SRS
+}{m: make(map[int]*SRS)}
+
+// ForTests returns the SRS of TEST_TAU for circuits of up to 2^logsize
+// constraints. It is generated once per process and size, so the tests of a
+// package share it and its Lagrange forms.
+func ForTests(logsize int) (*SRS, error) {
+	shared.Lock()
+	defer shared.Unlock()
+	if s := shared.m[logsize]; s != nil {
+		return s, nil
+	}
+	s, err := NewTestSRS(uint64(1)<<logsize+3, TEST_TAU)
+	if err != nil {
+		return nil, err
+	}
+	shared.m[logsize] = s
+	return s, nil
+}
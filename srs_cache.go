@@ -0,0 +1,147 @@
+package eonark
+
+import (
+	"math/bits"
+	"sync"
+	"unsafe"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+)
+
+// SRSCache shares the SRS points between all the Pk of the process: each CK
+// prefix and Lagrange size is read and checked once, later proofs skip the
+// SRS I/O. The points are kept by the hash they were checked against, any
+// source of the same SRS hits the same entries.
+type SRSCache struct {
+	mu      sync.Mutex
+	budget  int64
+	size    int64
+	tick    uint64
+	entries map[string]*srsCacheEntry
+}
+
+type srsCacheEntry struct {
+	load     sync.Mutex // held while the points are read
+	points   []bls12381.G1Affine
+	verified bool
+	size     int64 // bytes accounted in the cache, guarded by SRSCache.mu
+	used     uint64
+}
+
+// SRS_CACHE is the cache the Pk read their SRS from.
+var SRS_CACHE = NewSRSCache(0)
+
+// NewSRSCache returns a cache keeping up to budget bytes of points, the
+// least recently used being dropped first. A budget of 0 is no limit.
+func NewSRSCache(budget int64) *SRSCache {
+	return &SRSCache{budget: budget, entries: make(map[string]*srsCacheEntry)}
+}
+
+// ProvingKey is ReadProvingKeyFrom, reading only the points not in the cache.
+func (me *SRSCache) ProvingKey(src SRSSource, sc, sl int) (ck kzg.ProvingKey, lk kzg.ProvingKey, err error) {
+	if err = checkSRSLKSize(sl); err != nil {
+		return
+	}
+	ck.G1, err = me.get("CK."+SRS_CK_HASH, sc, func() ([]bls12381.G1Affine, error) {
+		return readSRSCK(src, sc)
+	}, func(points []bls12381.G1Affine) error {
+		return VerifySRS(points)
+	})
+	if err != nil {
+		return
+	}
+	lk.G1, err = me.get("LK."+SRS_LK_HASH[bits.TrailingZeros(uint(sl))], sl, func() ([]bls12381.G1Affine, error) {
+		return readSRSLK(ck.G1, sl)
+	}, func(points []bls12381.G1Affine) error {
+		return verifySRSLK(ck.G1, points)
+	})
+	return
+}
+
+// SetBudget changes the budget of the cache, dropping points past it.
+func (me *SRSCache) SetBudget(budget int64) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.budget = budget
+	me.evict(nil)
+}
+
+// Size returns the bytes of points kept by the cache.
+func (me *SRSCache) Size() int64 {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.size
+}
+
+// Evict drops all the points, they stay in use by the proving keys already
+// holding them and are read again by the next ones.
+func (me *SRSCache) Evict() {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	for key, e := range me.entries {
+		e.size = 0
+		delete(me.entries, key)
+	}
+	me.size = 0
+}
+
+func (me *SRSCache) get(key string, n int, load func() ([]bls12381.G1Affine, error), verify func([]bls12381.G1Affine) error) ([]bls12381.G1Affine, error) {
+	me.mu.Lock()
+	e, ok := me.entries[key]
+	if !ok {
+		e = &srsCacheEntry{}
+		me.entries[key] = e
+	}
+	me.mu.Unlock()
+
+	e.load.Lock()
+	defer e.load.Unlock()
+	if len(e.points) < n {
+		points, err := load()
+		if err != nil {
+			return nil, err
+		}
+		e.points, e.verified = points, SRS_VERIFY
+	} else if SRS_VERIFY && !e.verified {
+		if err := verify(e.points); err != nil {
+			return nil, err
+		}
+		e.verified = true
+	}
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if _, ok := me.entries[key]; !ok {
+		// evicted while loading
+		me.entries[key] = e
+	}
+	if me.entries[key] == e {
+		size := int64(len(e.points)) * int64(unsafe.Sizeof(bls12381.G1Affine{}))
+		me.size += size - e.size
+		e.size = size
+		me.tick++
+		e.used = me.tick
+		me.evict(e)
+	}
+	return e.points[:n:n], nil
+}
+
+// evict drops the least recently used entries but keep until the cache fits
+// its budget.
+func (me *SRSCache) evict(keep *srsCacheEntry) {
+	for me.budget > 0 && me.size > me.budget {
+		var lru string
+		for key, e := range me.entries {
+			if e != keep && (lru == "" || e.used < me.entries[lru].used) {
+				lru = key
+			}
+		}
+		if lru == "" {
+			return
+		}
+		me.size -= me.entries[lru].size
+		me.entries[lru].size = 0
+		delete(me.entries, lru)
+	}
+}
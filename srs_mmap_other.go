@@ -0,0 +1,27 @@
+//go:build !unix
+
+package eonark
+
+import "os"
+
+type osSRSFile struct {
+	*os.File
+	size int64
+}
+
+func openSRSFile(name string) (SRSFile, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return osSRSFile{file, info.Size()}, nil
+}
+
+func (me osSRSFile) Size() int64 {
+	return me.size
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package eonark
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+type mmapSRSFile struct {
+	data []byte
+}
+
+func openSRSFile(name string) (SRSFile, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return &mmapSRSFile{}, nil
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapSRSFile{data}, nil
+}
+
+func (me *mmapSRSFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, os.ErrInvalid
+	}
+	if off >= int64(len(me.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, me.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (me *mmapSRSFile) Size() int64 {
+	return int64(len(me.data))
+}
+
+func (me *mmapSRSFile) Close() error {
+	if me.data == nil {
+		return nil
+	}
+	data := me.data
+	me.data = nil
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,54 @@
+package eonark_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/srs"
+)
+
+// pinTestSRS pins the hashes and KZG vk of srs.ForTests(logsize) in place of
+// the real SRS until the end of the test, with an empty cache dir, and returns
+// its points as a source. It is for the tests of the SRS sources and of what
+// leaves the process, which refuse keys of Pk.SetTestSRS.
+func pinTestSRS(t *testing.T, logsize int) eonark.SRSSource {
+	s, err := srs.ForTests(logsize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(g1 []bls12381.G1Affine) []byte {
+		var buf bytes.Buffer
+		for _, v := range g1 {
+			x, y := v.X.Bytes(), v.Y.Bytes()
+			buf.Write(x[:])
+			buf.Write(y[:])
+		}
+		return buf.Bytes()
+	}
+	ck := encode(s.CK)
+	sum := sha256.Sum256(ck)
+	hashck, hashidx, hashlk, vk, dir := eonark.SRS_CK_HASH, eonark.SRS_CK_IDX_HASH, slices.Clone(eonark.SRS_LK_HASH), eonark.SRS_VK, eonark.DATA_CACHE_DIR
+	t.Cleanup(func() {
+		eonark.SRS_CK_HASH, eonark.SRS_CK_IDX_HASH, eonark.SRS_LK_HASH, eonark.SRS_VK, eonark.DATA_CACHE_DIR = hashck, hashidx, hashlk, vk, dir
+	})
+	eonark.SRS_CK_HASH = hex.EncodeToString(sum[:])
+	sum = sha256.Sum256(srsIndex(ck))
+	eonark.SRS_CK_IDX_HASH = hex.EncodeToString(sum[:])
+	eonark.SRS_VK = s.Vk
+	eonark.DATA_CACHE_DIR = t.TempDir()
+	for i := 0; i <= logsize; i++ {
+		_, lk, err := s.ProvingKey(0, 1<<i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(encode(lk.G1))
+		eonark.SRS_LK_HASH[i] = hex.EncodeToString(sum[:])
+	}
+	return eonark.MemorySRS(ck)
+}
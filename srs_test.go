@@ -0,0 +1,338 @@
+package eonark_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+	"github.com/eon-protocol/eonark/srs"
+)
+
+func TestVerifySRS(t *testing.T) {
+	src := pinTestSRS(t, 6)
+	verify := eonark.SRS_VERIFY
+	t.Cleanup(func() { eonark.SRS_VERIFY = verify })
+	eonark.SRS_VERIFY = true
+	ck, lk, err := eonark.ReadProvingKeyFrom(src, 1<<6+3, 1<<6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, err := kzg.ToLagrangeG1(ck.G1[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eonark.VerifySRS(ck.G1, lk.G1, small); err != nil {
+		t.Fatal(err)
+	}
+
+	// a point on the curve but outside of the G1 subgroup
+	var outside bls12381.G1Affine
+	for x := uint64(1); ; x++ {
+		var y2 fp.Element
+		outside.X.SetUint64(x)
+		y2.Square(&outside.X).Mul(&y2, &outside.X).Add(&y2, new(fp.Element).SetUint64(4))
+		if outside.Y.Sqrt(&y2) != nil && !outside.IsInSubGroup() {
+			break
+		}
+	}
+	other, err := kzg.NewSRS(1<<6+3, big.NewInt(43))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]struct {
+		ck  []bls12381.G1Affine
+		lks [][]bls12381.G1Affine
+	}{
+		"point outside of the subgroup": {slices.Concat(ck.G1[:5], []bls12381.G1Affine{outside}, ck.G1[6:]), nil},
+		"other tau":                     {other.Pk.G1, nil},
+		"swapped ck points":             {slices.Concat(ck.G1[:5], ck.G1[6:7], ck.G1[5:6], ck.G1[7:]), nil},
+		"swapped lk points":             {ck.G1, [][]bls12381.G1Affine{slices.Concat(lk.G1[1:2], lk.G1[:1], lk.G1[2:])}},
+		"lk of other tau":               {ck.G1, [][]bls12381.G1Affine{small, other.Pk.G1[:1<<6]}},
+	} {
+		if eonark.VerifySRS(v.ck, v.lks...) == nil {
+			t.Fatalf("srs accepted with %s", name)
+		}
+	}
+}
+
+type countingSRS struct {
+	eonark.SRSSource
+	opened *atomic.Int32
+}
+
+func (me countingSRS) OpenCK() (eonark.SRSFile, error) {
+	me.opened.Add(1)
+	return me.SRSSource.OpenCK()
+}
+
+func TestSRSCache(t *testing.T) {
+	src := countingSRS{pinTestSRS(t, 6), new(atomic.Int32)}
+	cache := eonark.NewSRSCache(0)
+	var wg sync.WaitGroup
+	cks := make([]kzg.ProvingKey, 8)
+	for i := range cks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if cks[i], _, err = cache.ProvingKey(src, 1<<5+3, 1<<5); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if src.opened.Load() != 1 || &cks[0].G1[0] != &cks[7].G1[0] {
+		t.Fatalf("srsck opened %d times for the same prefix", src.opened.Load())
+	}
+	ck, lk, err := cache.ProvingKey(src, 1<<6+3, 1<<6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.opened.Load() != 2 || len(ck.G1) != 1<<6+3 || len(lk.G1) != 1<<6 {
+		t.Fatal("longer srsck prefix not read")
+	}
+	if _, _, err := cache.ProvingKey(src, 1<<4+3, 1<<4); err != nil || src.opened.Load() != 2 {
+		t.Fatalf("shorter srsck prefix read again: %v", err)
+	}
+	want, _, err := eonark.ReadProvingKeyFrom(src, 1<<6+3, 1<<6)
+	if err != nil || !slices.Equal(ck.G1, want.G1) {
+		t.Fatalf("cached srsck differs: %v", err)
+	}
+
+	// the least recently used points are dropped past the budget
+	size := cache.Size()
+	cache.SetBudget(size - 1)
+	if cache.Size() >= size {
+		t.Fatal("nothing evicted past the budget")
+	}
+	cache.Evict()
+	if cache.Size() != 0 {
+		t.Fatalf("%d bytes left after Evict", cache.Size())
+	}
+	opened := src.opened.Load()
+	if _, _, err := cache.ProvingKey(src, 1<<4+3, 1<<4); err != nil || src.opened.Load() != opened+1 {
+		t.Fatalf("srsck not read again after Evict: %v", err)
+	}
+}
+
+// testSRS returns the test SRS for circuits of up to 2^logsize constraints.
+func testSRS(t *testing.T, logsize int) *srs.SRS {
+	s, err := srs.ForTests(logsize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTestSRS(t *testing.T) {
+	s, err := srs.NewTestSRS(1<<6+3, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := srs.NewTestSRS(1<<6+3, big.NewInt(7)); err != nil || !slices.Equal(s.CK, again.CK) || s.Vk != again.Vk {
+		t.Fatalf("test srs not deterministic: %v", err)
+	}
+	var pk eonark.Pk
+	pk.SetTestSRS(s)
+	if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	assignment := publicsCircuit{Publics: []frontend.Variable{1, 2}, Sum: 3}
+	publics, _, proof, err := pk.Prove(&assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vk.Verify(proof, publics); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eonark.VerifyBatch([]*eonark.Vk{&vk, &vk}, []*eonark.Proof{proof, proof}, [][]fr.Element{publics, publics}); err != nil {
+		t.Fatal(err)
+	}
+	public, err := frontend.NewWitness(&assignment, eonark.FIELD, frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plonk.Verify(proof.ToGnarkPRoof(), vk.ToGnarkVerifyingKey(), public, eonark.OPT_VERIFIER); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same key without the test srs is a production key
+	prod := vk
+	prod.SetTestSRS(nil)
+	if prod.Verify(proof, publics) == nil {
+		t.Fatal("test srs proof accepted by a production key")
+	}
+	if _, err := eonark.VerifyBatch([]*eonark.Vk{&vk, &prod}, []*eonark.Proof{proof, proof}, [][]fr.Element{publics, publics}); err == nil {
+		t.Fatal("batch of test and production keys verified")
+	}
+	if _, err := vk.WriteTo(io.Discard); err == nil {
+		t.Fatal("test srs key serialized")
+	}
+	if _, err := json.Marshal(&vk); err == nil {
+		t.Fatal("test srs key encoded")
+	}
+	if err := vk.ExportSolidity(io.Discard); err == nil {
+		t.Fatal("test srs key exported")
+	}
+	store := eonark.KeyStore{Dir: t.TempDir()}
+	if err := store.Save(&pk); err == nil {
+		t.Fatal("test srs key saved")
+	}
+}
+
+func TestHTTPSRS(t *testing.T) {
+	ck := []byte(pinTestSRS(t, 6).(eonark.MemorySRS))
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	forged := slices.Clone(ck)
+	forged[100] ^= 1
+	wrong := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "SRS.CK.BIN", time.Time{}, bytes.NewReader(forged))
+	}))
+	defer wrong.Close()
+	// the first response is cut after half of the points, the next ones
+	// honour the range
+	var requests atomic.Int32
+	var ranges []string
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(ck)))
+			w.Write(ck[:len(ck)/2])
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "SRS.CK.BIN", time.Time{}, bytes.NewReader(ck))
+	}))
+	defer good.Close()
+
+	cache := path.Join(eonark.DATA_CACHE_DIR, "SRS.CK.BIN")
+	src := eonark.HTTPSRS{Mirrors: []string{wrong.URL}, Cache: cache, Retries: 2}
+	if _, err := src.OpenCK(); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("srsck of wrong hash accepted: %v", err)
+	}
+	if _, err := os.Stat(cache + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("srsck of wrong hash kept: %v", err)
+	}
+
+	src.Mirrors = []string{down.URL, wrong.URL, good.URL}
+	file, err := src.OpenCK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, file.Size())
+	if _, err := file.ReadAt(got, 0); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if !bytes.Equal(got, ck) {
+		t.Fatal("downloaded srsck differs")
+	}
+	if want := fmt.Sprintf("bytes=%d-", len(ck)/2); !slices.Equal(ranges, []string{want}) {
+		t.Fatalf("truncated srsck not resumed: ranges %q, want %q", ranges, want)
+	}
+
+	// the cache is used once downloaded
+	good.Close()
+	src.Mirrors = []string{good.URL}
+	if file, err := src.OpenCK(); err != nil {
+		t.Fatalf("cached srsck not used: %v", err)
+	} else {
+		file.Close()
+	}
+}
+
+func TestParseSRSSource(t *testing.T) {
+	if src, err := eonark.ParseSRSSource(""); err != nil || src == nil {
+		t.Fatalf("default srs source: %v", err)
+	}
+	src, err := eonark.ParseSRSSource("https://a.example/SRS.CK.BIN,http://b.example/SRS.CK.BIN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := src.(eonark.HTTPSRS); !ok || !slices.Equal(h.Mirrors, []string{"https://a.example/SRS.CK.BIN", "http://b.example/SRS.CK.BIN"}) {
+		t.Fatalf("srs mirrors parsed as %#v", src)
+	}
+	file := path.Join(t.TempDir(), "SRS.CK.BIN")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if src, err := eonark.ParseSRSSource(file); err != nil || src != eonark.FileSRS(file) {
+		t.Fatalf("srs file parsed as %#v: %v", src, err)
+	}
+	if _, err := eonark.ParseSRSSource(file + ".missing"); err == nil {
+		t.Fatal("missing srs file accepted")
+	}
+}
+
+// srsIndex returns the chunk index of ck, as written by eonark.CheckSRSCK.
+func srsIndex(ck []byte) []byte {
+	idx := binary.BigEndian.AppendUint64(nil, uint64(len(ck)))
+	for i := 0; i < len(ck); i += eonark.SRS_CHUNK_POINTS * 96 {
+		sum := sha256.Sum256(ck[i:min(i+eonark.SRS_CHUNK_POINTS*96, len(ck))])
+		idx = append(idx, sum[:]...)
+	}
+	return idx
+}
+
+func TestCheckSRSCKPrefix(t *testing.T) {
+	ck := []byte(pinTestSRS(t, 6).(eonark.MemorySRS))
+	idxpath := path.Join(eonark.DATA_CACHE_DIR, fmt.Sprintf("SRS.CK.%s.IDX", eonark.SRS_CK_HASH))
+	check := func(data, idx []byte) error {
+		file := path.Join(t.TempDir(), "SRS.CK.BIN")
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(idxpath, idx, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := eonark.FileSRS(file).OpenCK()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		return eonark.CheckSRSCKPrefix(f, 1)
+	}
+	if err := check(ck, srsIndex(ck)); err != nil {
+		t.Fatal(err)
+	}
+	// a tampered ck with an index forged to match it
+	tampered := slices.Clone(ck)
+	tampered[0] ^= 1
+	if check(tampered, srsIndex(tampered)) == nil {
+		t.Fatal("tampered srsck accepted with a forged index")
+	}
+	// without a pinned index the whole ck is hashed
+	eonark.SRS_CK_IDX_HASH = ""
+	if check(tampered, srsIndex(tampered)) == nil {
+		t.Fatal("tampered srsck accepted with an unpinned index")
+	}
+	if err := check(ck, srsIndex(ck)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+package eonark
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	"golang.org/x/sync/errgroup"
+)
+
+// VerifySRS checks the points themselves instead of the hash of the files:
+// every point of ck and lks is in the G1 subgroup, ck are the powers of the
+// tau of SRS_VK and each lk is the Lagrange basis of the first len(lk) of
+// them. The last two are checked on random linear combinations of the points.
+func VerifySRS(ck []bls12381.G1Affine, lks ...[]bls12381.G1Affine) error {
+	if len(ck) < 2 {
+		return errors.New("srsck has less than 2 points")
+	}
+	if !ck[0].Equal(&SRS_VK.G1) {
+		return errors.New("srsck does not start with the generator of SRS_VK")
+	}
+	if err := checkSRSSubgroup("srsck", ck); err != nil {
+		return err
+	}
+	if err := checkSRSPowers(ck); err != nil {
+		return err
+	}
+	for _, lk := range lks {
+		if err := verifySRSLK(ck, lk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySRSLK is VerifySRS of lk once ck is verified.
+func verifySRSLK(ck, lk []bls12381.G1Affine) error {
+	if err := checkSRSSubgroup("srslk", lk); err != nil {
+		return err
+	}
+	return checkSRSLagrange(ck, lk)
+}
+
+func checkSRSSubgroup(name string, points []bls12381.G1Affine) error {
+	g := new(errgroup.Group)
+	g.SetLimit(runtime.NumCPU())
+	for start := 0; start < len(points); start += SRS_CHUNK_POINTS {
+		g.Go(func() error {
+			for i := start; i < min(start+SRS_CHUNK_POINTS, len(points)); i++ {
+				if !points[i].IsOnCurve() {
+					return fmt.Errorf("%s point %d is not on the curve", name, i)
+				}
+				if !points[i].IsInSubGroup() {
+					return fmt.Errorf("%s point %d is not in the G1 subgroup", name, i)
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// checkSRSPowers checks e(Σ rⁱ⋅ck[i], τ⋅G2) = e(Σ rⁱ⋅ck[i+1], G2), which
+// holds for a random r only if ck[i+1] = τ⋅ck[i] for all i.
+func checkSRSPowers(ck []bls12381.G1Affine) error {
+	r := randomPowers(len(ck) - 1)
+	var a, b bls12381.G1Affine
+	if _, err := a.MultiExp(ck[:len(ck)-1], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := b.MultiExp(ck[1:], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	b.Neg(&b)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{a, b}, []bls12381.G2Affine{SRS_VK.G2[1], SRS_VK.G2[0]})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("srsck is not the powers of the tau of SRS_VK")
+	}
+	return nil
+}
+
+// checkSRSLagrange checks Σ rʲ⋅lk[j] = Σ cᵢ⋅ck[i] with c the inverse FFT of
+// the rʲ, lk[j] = Σ ω⁻ⁱʲ/n⋅ck[i] being the inverse FFT of ck.
+func checkSRSLagrange(ck, lk []bls12381.G1Affine) error {
+	n := len(lk)
+	if bits.OnesCount(uint(n)) != 1 || n > len(ck) {
+		return fmt.Errorf("invalid srslk size %d", n)
+	}
+	r := randomPowers(n)
+	c := make([]fr.Element, n)
+	copy(c, r)
+	fft.NewDomain(uint64(n)).FFTInverse(c, fft.DIF)
+	fft.BitReverse(c)
+	var a, b bls12381.G1Affine
+	if _, err := a.MultiExp(lk, r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := b.MultiExp(ck[:n], c, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if !a.Equal(&b) {
+		return fmt.Errorf("srslk of %d points is not the Lagrange basis of srsck", n)
+	}
+	return nil
+}
+
+func randomPowers(n int) []fr.Element {
+	var r fr.Element
+	if _, err := r.SetRandom(); err != nil {
+		panic(err)
+	}
+	ret := make([]fr.Element, n)
+	ret[0].SetOne()
+	for i := 1; i < n; i++ {
+		ret[i].Mul(&ret[i-1], &r)
+	}
+	return ret
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/eon-protocol/eonark"
+
+	// the circuits the commands know
+	_ "github.com/eon-protocol/eonark/accounts/permissionless"
+)
+
+// loadCircuit returns the circuit of -circuit, a registered name or the hex
+// address of its key, or else of the key of -pk, and its proving key.
+func (me *options) loadCircuit() (*eonark.Circuit, *eonark.Pk, error) {
+	store, err := me.store()
+	if err != nil {
+		return nil, nil, err
+	}
+	if me.pk != "" {
+		pk, err := me.loadPk()
+		if err != nil {
+			return nil, nil, err
+		}
+		if me.circuit == "" {
+			vk := pk.Vk()
+			circuit, _, err := store.CircuitByAddress(vk.Address())
+			return circuit, pk, err
+		}
+		circuit, err := eonark.CircuitByName(me.circuit)
+		return circuit, pk, err
+	}
+	if me.circuit == "" {
+		return nil, nil, errors.New("one of -circuit or -pk is required")
+	}
+	circuit, err := eonark.CircuitByName(me.circuit)
+	if err == nil {
+		pk, err := store.LoadCircuit(me.circuit)
+		return circuit, pk, err
+	}
+	var address fr.Element
+	if _, aerr := address.SetString("0x" + strings.TrimPrefix(me.circuit, "0x")); aerr != nil {
+		return nil, nil, err
+	}
+	return store.CircuitByAddress(address)
+}
+
+func circuitNames() string {
+	return strings.Join(eonark.Circuits(), ", ")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/eon-protocol/eonark"
+)
+
+// inspection describes a key or proof, the fields not known for its kind
+// are left out.
+type inspection struct {
+	Kind          string
+	Version       uint16
+	Address       string `json:",omitempty"`
+	Constraints   int    `json:",omitempty"`
+	Size          int    `json:",omitempty"`
+	NbPublic      uint32 `json:",omitempty"`
+	NbCommitments int
+}
+
+func runInspect(args []string) (err error) {
+	var o options
+	fs := o.flags("inspect", false, "text", "json")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	in, err := o.input()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	ret, err := inspect(data)
+	if err != nil {
+		return err
+	}
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	if o.format == "json" {
+		return encode(out, o.format, nil, ret)
+	}
+	fmt.Fprintln(out, "kind:", ret.Kind)
+	fmt.Fprintln(out, "version:", ret.Version)
+	if ret.Address != "" {
+		fmt.Fprintln(out, "address:", ret.Address)
+		fmt.Fprintln(out, "domain size:", ret.Size)
+		fmt.Fprintln(out, "public inputs:", ret.NbPublic)
+	}
+	if ret.Constraints != 0 {
+		fmt.Fprintln(out, "constraints:", ret.Constraints)
+	}
+	_, err = fmt.Fprintln(out, "commitments:", ret.NbCommitments)
+	return err
+}
+
+func inspect(data []byte) (*inspection, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		// JSON keys have an S1, proofs a CW1
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			return nil, err
+		}
+		if _, ok := fields["Proof"]; ok {
+			trimmed = fields["Proof"]
+		} else if _, ok := fields["S1"]; ok {
+			var vk eonark.Vk
+			if err := json.Unmarshal(trimmed, &vk); err != nil {
+				return nil, err
+			}
+			return inspectVk(&vk, 0), nil
+		}
+		var proof eonark.Proof
+		if err := json.Unmarshal(trimmed, &proof); err != nil {
+			return nil, err
+		}
+		return &inspection{Kind: eonark.KIND_PROOF.String(), NbCommitments: len(proof.BSB)}, nil
+	}
+	if raw, err := hex.DecodeString(string(trimmed)); err == nil {
+		data = raw
+	}
+	// the framed layout: magic | version u16 | curve u16 | kind u8 | ...
+	var version uint16
+	kinds := []eonark.Kind{eonark.KIND_VK, eonark.KIND_PROOF}
+	if magic := len(eonark.CONTAINER_MAGIC); len(data) >= eonark.CONTAINER_HEADER_SIZE && string(data[:magic]) == eonark.CONTAINER_MAGIC {
+		version = binary.BigEndian.Uint16(data[magic:])
+		kinds = []eonark.Kind{eonark.Kind(data[magic+4])}
+	}
+	for _, kind := range kinds {
+		r := bytes.NewReader(data)
+		switch kind {
+		case eonark.KIND_VK:
+			var vk eonark.Vk
+			if _, err := vk.ReadFrom(r); err == nil && r.Len() == 0 {
+				return inspectVk(&vk, version), nil
+			} else if len(kinds) == 1 {
+				return nil, err
+			}
+		case eonark.KIND_PROOF:
+			var proof eonark.Proof
+			if _, err := proof.ReadFrom(r); err == nil && r.Len() == 0 {
+				return &inspection{Kind: kind.String(), Version: version, NbCommitments: len(proof.BSB)}, nil
+			} else if len(kinds) == 1 {
+				return nil, err
+			}
+		case eonark.KIND_PK:
+			var pk eonark.Pk
+			if _, err := pk.ReadFrom(r); err != nil {
+				return nil, err
+			}
+			vk := pk.Vk()
+			ret := inspectVk(&vk, version)
+			ret.Kind = kind.String()
+			ret.Constraints = pk.ToGnarkConstraintSystem().GetNbConstraints()
+			return ret, nil
+		default:
+			return nil, fmt.Errorf("unknown container %v", kind)
+		}
+	}
+	return nil, errors.New("neither a verifying key nor a proof")
+}
+
+func inspectVk(vk *eonark.Vk, version uint16) *inspection {
+	addr := vk.Address()
+	return &inspection{
+		Kind:          eonark.KIND_VK.String(),
+		Version:       version,
+		Address:       addr.Text(16),
+		Size:          1 << vk.SZ,
+		NbPublic:      vk.NP,
+		NbCommitments: len(vk.QC),
+	}
+}
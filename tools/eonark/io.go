@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/eon-protocol/eonark"
+)
+
+// options are the flags shared by the commands, each command registers the
+// ones it uses.
+type options struct {
+	srs     string
+	circuit string
+	keys    string
+	pk      string
+	vk      string
+	in      string
+	out     string
+	publics string
+	format  string
+	formats []string
+	batch   bool
+}
+
+// flags registers the common flags, the first of formats is the default.
+func (me *options) flags(name string, keys bool, formats ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&me.srs, "srs", "", "srs source: local file path or comma-separated http(s) mirror urls")
+	fs.StringVar(&me.in, "in", "-", "input file, - for stdin")
+	fs.StringVar(&me.out, "o", "-", "output file, - for stdout")
+	if keys {
+		fs.StringVar(&me.circuit, "circuit", "", "registered circuit, by name or address: "+circuitNames())
+		fs.StringVar(&me.keys, "keys", "", "key store directory of the compiled circuits (default the eonark cache)")
+		fs.StringVar(&me.pk, "pk", "", "proving key file, instead of compiling -circuit")
+	}
+	if len(formats) > 0 {
+		me.formats = formats
+		fs.StringVar(&me.format, "format", formats[0], "output format: "+strings.Join(formats, ", "))
+	}
+	return fs
+}
+
+func (me *options) vkFlag(fs *flag.FlagSet) {
+	fs.StringVar(&me.vk, "vk", "", "verifying key file, instead of -pk or -circuit")
+}
+
+func (me *options) batchFlag(fs *flag.FlagSet, what string) {
+	fs.BoolVar(&me.batch, "batch", false, "read "+what+" per line of a JSONL input and write one output per line")
+}
+
+// parse parses args, there are no positional arguments.
+func (me *options) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if len(me.formats) > 0 && !slices.Contains(me.formats, me.format) {
+		return fmt.Errorf("unsupported format %q, one of: %s", me.format, strings.Join(me.formats, ", "))
+	}
+	return nil
+}
+
+func (me *options) source() (eonark.SRSSource, error) {
+	return eonark.ParseSRSSource(me.srs)
+}
+
+func (me *options) store() (*eonark.KeyStore, error) {
+	src, err := me.source()
+	if err != nil {
+		return nil, err
+	}
+	store := eonark.KEY_STORE
+	store.Source = src
+	if me.keys != "" {
+		store.Dir = me.keys
+	}
+	return &store, os.MkdirAll(store.Dir, os.ModePerm)
+}
+
+// loadPk reads -pk, or loads the key of -circuit from the key store,
+// compiling it the first time.
+func (me *options) loadPk() (*eonark.Pk, error) {
+	if me.pk == "" {
+		_, pk, err := me.loadCircuit()
+		return pk, err
+	}
+	src, err := me.source()
+	if err != nil {
+		return nil, err
+	}
+	var pk eonark.Pk
+	if err := readFile(me.pk, &pk, nil); err != nil {
+		return nil, fmt.Errorf("%s: %w", me.pk, err)
+	}
+	pk.SetSRSSource(src)
+	return &pk, nil
+}
+
+// loadVk reads -vk, or takes the key of the proving key of loadPk.
+func (me *options) loadVk() (*eonark.Vk, error) {
+	if me.vk != "" {
+		var vk eonark.Vk
+		if err := readFile(me.vk, &vk, &vk); err != nil {
+			return nil, fmt.Errorf("%s: %w", me.vk, err)
+		}
+		return &vk, nil
+	}
+	pk, err := me.loadPk()
+	if err != nil {
+		return nil, err
+	}
+	vk := pk.Vk()
+	return &vk, nil
+}
+
+func (me *options) input() (io.ReadCloser, error) {
+	if me.in == "" || me.in == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(me.in)
+}
+
+func (me *options) output() (io.WriteCloser, error) {
+	if me.out == "" || me.out == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(me.out)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// lines calls f on every non empty line of r, numbered from 1.
+func lines(r io.Reader, f func(n int, line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := f(n, line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func readFile(name string, bin io.ReaderFrom, js any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return decode(data, bin, js)
+}
+
+// decode reads data written as JSON into js, or as hex or binary with bin. A
+// nil js has no JSON form.
+func decode(data []byte, bin io.ReaderFrom, js any) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if js == nil {
+			return errors.New("no JSON form")
+		}
+		return json.Unmarshal(trimmed, js)
+	}
+	if raw, err := hex.DecodeString(string(trimmed)); err == nil {
+		data = raw
+	}
+	_, err := bin.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// encode writes bin in the binary container format, as hex or as JSON, one
+// line for the last two. A nil js has no JSON form.
+func encode(w io.Writer, format string, bin io.WriterTo, js any) error {
+	switch format {
+	case "json":
+		if js == nil {
+			return errors.New("no JSON form")
+		}
+		return json.NewEncoder(w).Encode(js)
+	case "bin":
+		_, err := bin.WriteTo(w)
+		return err
+	case "hex":
+		if _, err := bin.WriteTo(hex.NewEncoder(w)); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
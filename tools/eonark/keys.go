@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/eon-protocol/eonark"
+)
+
+func runCompile(args []string) (err error) {
+	var o options
+	fs := o.flags("compile", true, "hex", "bin")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	if o.circuit == "" {
+		return errors.New("-circuit is required")
+	}
+	circuit, pk, err := o.loadCircuit()
+	if err != nil {
+		return err
+	}
+	vk := pk.Vk()
+	addr := vk.Address()
+	log.Println("compiled", circuit.Name, "address", addr.Text(16))
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	return encode(out, o.format, pk, nil)
+}
+
+func runAddress(args []string) (err error) {
+	var o options
+	fs := o.flags("address", true, "hex", "json")
+	o.vkFlag(fs)
+	o.batchFlag(fs, "one verifying key")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	write := func(vk *eonark.Vk) error {
+		addr := vk.Address()
+		if o.format == "json" {
+			return encode(out, o.format, nil, struct{ Address string }{addr.Text(16)})
+		}
+		_, err := fmt.Fprintln(out, addr.Text(16))
+		return err
+	}
+	if o.batch {
+		return batchVks(&o, write)
+	}
+	vk, err := o.loadVk()
+	if err != nil {
+		return err
+	}
+	return write(vk)
+}
+
+func runExportVk(args []string) (err error) {
+	var o options
+	fs := o.flags("export-vk", true, "hex", "bin", "json", "solidity")
+	o.vkFlag(fs)
+	o.batchFlag(fs, "one verifying key")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	if o.batch && o.format == "solidity" {
+		return errors.New("the solidity format has no batch mode")
+	}
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	write := func(vk *eonark.Vk) error {
+		if o.format == "solidity" {
+			return vk.ExportSolidity(out)
+		}
+		return encode(out, o.format, vk, vk)
+	}
+	if o.batch {
+		return batchVks(&o, write)
+	}
+	vk, err := o.loadVk()
+	if err != nil {
+		return err
+	}
+	return write(vk)
+}
+
+// batchVks calls f on every verifying key of the JSONL input.
+func batchVks(o *options, f func(vk *eonark.Vk) error) error {
+	in, err := o.input()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return lines(in, func(_ int, line []byte) error {
+		var vk eonark.Vk
+		if err := decode(line, &vk, &vk); err != nil {
+			return err
+		}
+		return f(&vk)
+	})
+}
+
+func closeOutput(out io.Closer, err *error) {
+	if cerr := out.Close(); *err == nil {
+		*err = cerr
+	}
+}
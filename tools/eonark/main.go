@@ -0,0 +1,62 @@
+// eonark compiles, proves and verifies the registered circuits and manages
+// the SRS, see README.md.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"compile":   {"compile -circuit <name> [-o <pk>]", runCompile},
+	"prove":     {"prove (-circuit <name> | -pk <pk>) [-in <assignment.json>] [-batch] [-o <proof>]", runProve},
+	"verify":    {"verify (-circuit <name> | -pk <pk> | -vk <vk>) [-in <proof>] [-publics <a,b,...>] [-batch]", runVerify},
+	"address":   {"address (-circuit <name> | -pk <pk> | -vk <vk>) [-batch]", runAddress},
+	"export-vk": {"export-vk (-circuit <name> | -pk <pk> | -vk <vk>) [-format solidity] [-batch] [-o <vk>]", runExportVk},
+	"inspect":   {"inspect [-in <vk|proof|pk>]", runInspect},
+	"srs":       {"srs (fetch | verify | lagrange) [-srs <source>]", runSRS},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: eonark <command> [flags]")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  eonark", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "circuits:", circuitNames())
+	fmt.Fprintln(os.Stderr, "run eonark <command> -h for the flags of a command")
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		if os.Args[1] != "-h" && os.Args[1] != "help" {
+			fmt.Fprintln(os.Stderr, "unknown command", os.Args[1])
+		}
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/eon-protocol/eonark"
+)
+
+// proved is the JSON form of a proof with its public inputs, the only one
+// carrying the public inputs.
+type proved struct {
+	Proof   *eonark.Proof
+	Publics eonark.PublicInputs
+}
+
+func runProve(args []string) (err error) {
+	var o options
+	fs := o.flags("prove", true, "hex", "bin", "json")
+	o.batchFlag(fs, "one assignment")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	c, pk, err := o.loadCircuit()
+	if err != nil {
+		return err
+	}
+	if o.batch {
+		if err := pk.Precompute(); err != nil {
+			return err
+		}
+	}
+	defer pk.Close()
+	in, err := o.input()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	prove := func(data []byte) error {
+		assignment, err := c.DecodeAssignment(data)
+		if err != nil {
+			return err
+		}
+		publics, _, proof, err := pk.Prove(assignment)
+		if err != nil {
+			return err
+		}
+		return encode(out, o.format, proof, proved{proof, publics})
+	}
+	if o.batch {
+		return lines(in, func(_ int, line []byte) error {
+			return prove(line)
+		})
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return prove(data)
+}
+
+func runVerify(args []string) (err error) {
+	var o options
+	fs := o.flags("verify", true, "text", "json")
+	o.vkFlag(fs)
+	o.batchFlag(fs, "one proof with its public inputs")
+	fs.StringVar(&o.publics, "publics", "", "comma-separated public inputs of a hex or binary proof")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	vk, err := o.loadVk()
+	if err != nil {
+		return err
+	}
+	in, err := o.input()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := o.output()
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	var proofs []*eonark.Proof
+	var publics [][]fr.Element
+	var numbers []int
+	read := func(n int, data []byte) error {
+		proof, pub, err := decodeProved(data, o.publics)
+		if err != nil {
+			return err
+		}
+		proofs, publics, numbers = append(proofs, proof), append(publics, pub), append(numbers, n)
+		return nil
+	}
+	if o.batch {
+		err = lines(in, read)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(in); err == nil {
+			err = read(0, data)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	vks := make([]*eonark.Vk, len(proofs))
+	for i := range vks {
+		vks[i] = vk
+	}
+	failed, verr := eonark.VerifyBatch(vks, proofs, publics)
+	for i, n := range numbers {
+		valid := !slices.Contains(failed, i)
+		switch {
+		case o.format == "json":
+			err = encode(out, o.format, nil, struct {
+				Line  int `json:",omitempty"`
+				Valid bool
+			}{n, valid})
+		case !o.batch && valid:
+			_, err = fmt.Fprintln(out, "ok")
+		case !o.batch:
+			_, err = fmt.Fprintln(out, "invalid")
+		case valid:
+			_, err = fmt.Fprintf(out, "line %d: ok\n", n)
+		default:
+			_, err = fmt.Fprintf(out, "line %d: invalid\n", n)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return verr
+}
+
+// decodeProved reads a proved, a bare JSON proof or a hex or binary proof
+// whose public inputs are given by publics.
+func decodeProved(data []byte, publics string) (*eonark.Proof, []fr.Element, error) {
+	var val proved
+	if err := decode(data, (*readProof)(&val), &val); err != nil {
+		return nil, nil, err
+	}
+	if val.Proof == nil {
+		// a JSON proof without its public inputs
+		val.Proof = new(eonark.Proof)
+		if err := json.Unmarshal(data, val.Proof); err != nil {
+			return nil, nil, err
+		}
+	}
+	if publics != "" {
+		if err := val.Publics.SetStrings(strings.Split(publics, ",")...); err != nil {
+			return nil, nil, err
+		}
+	}
+	if val.Publics == nil {
+		return nil, nil, errors.New("no public inputs, give them with -publics")
+	}
+	return val.Proof, val.Publics, nil
+}
+
+// readProof reads the proof of a proved from hex or binary.
+type readProof proved
+
+func (me *readProof) ReadFrom(r io.Reader) (int64, error) {
+	me.Proof = new(eonark.Proof)
+	return me.Proof.ReadFrom(r)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	"github.com/eon-protocol/eonark"
+)
+
+var srsCommands = map[string]func(o *options) error{
+	"fetch":    srsFetch,
+	"verify":   srsVerify,
+	"lagrange": srsLagrange,
+}
+
+func runSRS(args []string) error {
+	if len(args) == 0 || srsCommands[args[0]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: eonark srs (fetch | verify | lagrange) [-srs <source>]")
+		return flag.ErrHelp
+	}
+	var o options
+	fs := flag.NewFlagSet("srs "+args[0], flag.ContinueOnError)
+	fs.StringVar(&o.srs, "srs", "", "srs source: local file path or comma-separated http(s) mirror urls")
+	if err := o.parse(fs, args[1:]); err != nil {
+		return err
+	}
+	return srsCommands[args[0]](&o)
+}
+
+// srsFetch downloads the CK if it is not cached and checks its sha256.
+func srsFetch(o *options) error {
+	src, err := o.source()
+	if err != nil {
+		return err
+	}
+	file, err := src.OpenCK()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := eonark.CheckSRSCK(file); err != nil {
+		return err
+	}
+	fmt.Println("srsck ok:", file.Size()/96, "points")
+	return nil
+}
+
+// srsVerify checks the points of the CK and of every cached Lagrange file,
+// whatever SRS_CK_HASH and SRS_LK_HASH say.
+func srsVerify(o *options) error {
+	ck, _, err := readCK(o)
+	if err != nil {
+		return err
+	}
+	var lks [][]bls12381.G1Affine
+	for i := 0; i < len(eonark.SRS_LK_HASH) && (1<<i) <= len(ck); i++ {
+		pathlk := path.Join(eonark.DATA_CACHE_DIR, fmt.Sprintf("SRS.LK.%v.BIN", i))
+		data, err := os.ReadFile(pathlk)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(data) != 96<<i {
+			return fmt.Errorf("invalid lk file %s of %d bytes", pathlk, len(data))
+		}
+		lk, err := eonark.ParseProvingKey(data, 1<<i)
+		if err != nil {
+			return err
+		}
+		lks = append(lks, lk)
+	}
+	if err := eonark.VerifySRS(ck, lks...); err != nil {
+		return err
+	}
+	fmt.Println("srs ok:", len(ck), "ck points,", len(lks), "lk files")
+	return nil
+}
+
+// srsLagrange prints the sha256 of the Lagrange forms of every size, the
+// SRS_LK_HASH, and of the per-chunk index of the CK, the SRS_CK_IDX_HASH.
+func srsLagrange(o *options) error {
+	ck, data, err := readCK(o)
+	if err != nil {
+		return err
+	}
+	for i := 0; (1 << i) <= len(ck); i++ {
+		lk, err := kzg.ToLagrangeG1(ck[:1<<i])
+		if err != nil {
+			return err
+		}
+		hasher := sha256.New()
+		for _, xy := range lk {
+			x, y := xy.X.Bytes(), xy.Y.Bytes()
+			hasher.Write(x[:])
+			hasher.Write(y[:])
+		}
+		fmt.Println("sha256", "(", "SRS.LK", "[", i, "]", ")", "=", hex.EncodeToString(hasher.Sum(nil)))
+	}
+	idx := binary.BigEndian.AppendUint64(nil, uint64(len(data)))
+	for i := 0; i < len(data); i += eonark.SRS_CHUNK_POINTS * 96 {
+		sum := sha256.Sum256(data[i:min(i+eonark.SRS_CHUNK_POINTS*96, len(data))])
+		idx = append(idx, sum[:]...)
+	}
+	sum := sha256.Sum256(idx)
+	fmt.Println("sha256", "(", "SRS.CK.IDX", ")", "=", hex.EncodeToString(sum[:]))
+	return nil
+}
+
+// readCK parses the whole CK of the source, without checking its sha256.
+func readCK(o *options) ([]bls12381.G1Affine, []byte, error) {
+	src, err := o.source()
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := src.OpenCK()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, file.Size()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data)%96 != 0 {
+		return nil, nil, fmt.Errorf("invalid ck file of %d bytes", len(data))
+	}
+	log.Println("read", len(data)/96, "ck points")
+	ck, err := eonark.ParseProvingKey(data, len(data)/96)
+	return ck, data, err
+}
@@ -9,19 +9,30 @@ import (
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/std/recursion/plonk"
+
+	"github.com/eon-protocol/eonark/zkcore"
 )
 
 const NUM_PUBLIC = 4
 const SRS_SIZE = (1 << 24) + 3
-const HASH_T = 2
-const HASH_RF = 8
-const HASH_RP = 56
+const HASH_T = zkcore.HASH_T
+const HASH_RF = zkcore.HASH_RF
+const HASH_RP = zkcore.HASH_RP
 const SRS_DOWNLOAD_URL = "https://github.com/eon-protocol/eonark/releases/download/bin/SRS.CK.BIN"
 
 var FIELD = ecc.BLS12_381.ScalarField()
 var OPT_PROVER = plonk.GetNativeProverOptions(FIELD, FIELD)
-var OPT_VERIFIER = plonk.GetNativeVerifierOptions(FIELD, FIELD)
+
+// OPT_VERIFIER makes gnark's plonk.Verify check eonark proofs:
+// plonk.Verify(proof.ToGnarkPRoof(), vk.ToGnarkVerifyingKey(), publicWitness, OPT_VERIFIER)
+var OPT_VERIFIER backend.VerifierOption = func(cfg *backend.VerifierConfig) error {
+	cfg.ChallengeHash = zkcore.NewChallengeHash()
+	cfg.KZGFoldingHash = zkcore.NewFoldingHash()
+	cfg.HashToFieldFn = zkcore.NewCommitmentHash()
+	return nil
+}
 var COSET_SHIFT = fr.NewElement(7)
 var DATA_CACHE_DIR = func() (dir string) {
 	var err error
@@ -34,26 +45,11 @@ var DATA_CACHE_DIR = func() (dir string) {
 	}
 	return
 }()
-var CID_GAMMA = func() (val fr.Element) {
-	val.SetString("12136437972164249638515815863518169381248623050802518443499856540155713785793")
-	return
-}()
-var CID_BETA = func() (val fr.Element) {
-	val.SetString("18573803297957083279407999548582433273399322018814582391185078724486099338357")
-	return
-}()
-var CID_ALPHA = func() (val fr.Element) {
-	val.SetString("49747578351961873600101888628702675272467029400415710410441263855875020310598")
-	return
-}()
-var CID_ZETA = func() (val fr.Element) {
-	val.SetString("39057712567180736910604556313519348712189848041390074835666431785905701131882")
-	return
-}()
-var PREFIX_BSB = func() (val fr.Element) {
-	val.SetString("25462560578134928990029001067183171577145376707459712415971543462128145703592")
-	return
-}()
+var CID_GAMMA = zkcore.CID_GAMMA
+var CID_BETA = zkcore.CID_BETA
+var CID_ALPHA = zkcore.CID_ALPHA
+var CID_ZETA = zkcore.CID_ZETA
+var PREFIX_BSB = zkcore.PREFIX_BSB
 var SRS_VK = func() (vk kzg.VerifyingKey) {
 	vk.G1.X.SetString("3685416753713387016781088315183077757961620795782546409894578378688607592378376318836054947676345821548104185464507")
 	vk.G1.Y.SetString("1339506544944476473020471379941921221584933875938349620426543736416511423956333506472724655353366534992391756441569")
@@ -70,6 +66,14 @@ var SRS_VK = func() (vk kzg.VerifyingKey) {
 	return
 }()
 var SRS_CK_HASH = "01c865c7a3e27cee83756164110f127420fcba42ffdc8b5966000ffc7dbb17bf"
+
+// SRS_CK_IDX_HASH is the sha256 of the chunk index of the CK, printed by
+// `eonark srs lagrange`. While it is unset every load hashes the whole CK.
+var SRS_CK_IDX_HASH = ""
+
+// SRS_VERIFY makes the SRS loads check the points with VerifySRS, not only
+// the sha256 of the files.
+var SRS_VERIFY = false
 var SRS_LK_HASH = []string{
 	"b1fbe330769a11acc36fc723335b0220323273e006f2b6fdb9db39ea82e7c183",
 	"0f5ebc416a150e367ade9f5272492625221884dae468e8e10239bbb734deeb14",
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/big"
 	"math/bits"
+	"runtime"
+	"slices"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
@@ -15,12 +18,37 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/kzg"
 	"github.com/consensys/gnark/backend/plonk"
 	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/eon-protocol/eonark/srs"
 )
 
+// Vk is a verifying key. NP is the number of public inputs, keys serialized
+// before it was stored have NUM_PUBLIC. QC and CI hold one selector commitment
+// and constraint index per BSB22 commitment of the circuit, in the order of
+// its api.Commit calls.
 type Vk struct {
-	S1, S2, S3, QL, QR, QM, QO, QK, QC bls12381.G1Affine
-	CI                                 uint32
-	SZ                                 uint8
+	S1, S2, S3, QL, QR, QM, QO, QK bls12381.G1Affine
+	QC                             []bls12381.G1Affine
+	CI                             []uint32
+	SZ                             uint8
+	NP                             uint32
+
+	testSRS *srs.SRS
+}
+
+// SetTestSRS makes me a key of the unsafe s, verified against its KZG key
+// instead of SRS_VK. Such keys are not serialized nor exported.
+func (me *Vk) SetTestSRS(s *srs.SRS) {
+	me.testSRS = s
+}
+
+// kzgVk is the KZG verifying key of the SRS of me.
+func (me *Vk) kzgVk() kzg.VerifyingKey {
+	if me.testSRS != nil {
+		return me.testSRS.Vk
+	}
+	return SRS_VK
 }
 
 func (me *Vk) ToGnarkVerifyingKey() plonk.VerifyingKey {
@@ -31,12 +59,16 @@ func (me *Vk) ToGnarkVerifyingKey() plonk.VerifyingKey {
 	if err != nil {
 		log.Fatalln(err)
 	}
+	cis := make([]uint64, len(me.CI))
+	for i, v := range me.CI {
+		cis[i] = uint64(v)
+	}
 	return &plonkbls12381.VerifyingKey{
 		Size:                        1 << me.SZ,
 		SizeInv:                     sizeinv,
 		Generator:                   generator,
-		NbPublicVariables:           NUM_PUBLIC,
-		Kzg:                         SRS_VK,
+		NbPublicVariables:           uint64(me.NP),
+		Kzg:                         me.kzgVk(),
 		CosetShift:                  fr.NewElement(7),
 		S:                           [3]bls12381.G1Affine{me.S1, me.S2, me.S3},
 		Ql:                          me.QL,
@@ -44,8 +76,8 @@ func (me *Vk) ToGnarkVerifyingKey() plonk.VerifyingKey {
 		Qm:                          me.QM,
 		Qo:                          me.QO,
 		Qk:                          me.QK,
-		Qcp:                         []bls12381.G1Affine{me.QC},
-		CommitmentConstraintIndexes: []uint64{uint64(me.CI)},
+		Qcp:                         slices.Clone(me.QC),
+		CommitmentConstraintIndexes: cis,
 	}
 }
 
@@ -54,20 +86,27 @@ func (me *Vk) FromGnarkVerifyingKey(vk plonk.VerifyingKey) error {
 	if bits.OnesCount64(cvk.Size) != 1 {
 		return errors.New("vk.size should be power of 2")
 	}
-	if cvk.NbPublicVariables != NUM_PUBLIC {
-		return fmt.Errorf("NbPublicVariables = %d != %d", cvk.NbPublicVariables, NUM_PUBLIC)
+	if cvk.NbPublicVariables == 0 || cvk.NbPublicVariables > math.MaxUint32 {
+		return fmt.Errorf("invalid NbPublicVariables = %d", cvk.NbPublicVariables)
 	}
-	if cvk.Kzg != SRS_VK {
+	if cvk.Kzg != me.kzgVk() {
 		return errors.New("invalid KZG VK")
 	}
 	if cvk.CosetShift != COSET_SHIFT {
 		return errors.New("invalid coset shift")
 	}
-	if len(cvk.Qcp) != 1 || len(cvk.CommitmentConstraintIndexes) != 1 {
+	if len(cvk.Qcp) != len(cvk.CommitmentConstraintIndexes) {
 		return errors.New("invalid number of commitments")
 	}
-	me.SZ = uint8(bits.TrailingZeros64(cvk.Size))      // TODO CHECK
-	me.CI = uint32(cvk.CommitmentConstraintIndexes[0]) // TODO CHECK
+	me.CI = make([]uint32, len(cvk.CommitmentConstraintIndexes))
+	for i, v := range cvk.CommitmentConstraintIndexes {
+		if v > math.MaxUint32 {
+			return fmt.Errorf("invalid commitment constraint index %d", v)
+		}
+		me.CI[i] = uint32(v)
+	}
+	me.SZ = uint8(bits.TrailingZeros64(cvk.Size)) // TODO CHECK
+	me.NP = uint32(cvk.NbPublicVariables)
 	me.S1 = cvk.S[0]
 	me.S2 = cvk.S[1]
 	me.S3 = cvk.S[2]
@@ -76,24 +115,134 @@ func (me *Vk) FromGnarkVerifyingKey(vk plonk.VerifyingKey) error {
 	me.QM = cvk.Qm
 	me.QO = cvk.Qo
 	me.QK = cvk.Qk
-	me.QC = cvk.Qcp[0]
+	me.QC = slices.Clone(cvk.Qcp)
 	return nil
 }
 
-func (me *Vk) Verify(proof *Proof, publics [4]fr.Element) error {
-	for _, v := range []bls12381.G1Affine{proof.CW1, proof.CW2, proof.CW3, proof.CPZ, proof.CH1, proof.CH2, proof.CH3, proof.BSB, proof.HBP, proof.HZO} {
+func (me *Vk) Verify(proof *Proof, publics []fr.Element) error {
+	digests, proofs, points, err := me.prepare(proof, publics)
+	if err != nil {
+		return err
+	}
+	return kzg.BatchVerifyMultiPoints(digests[:], proofs[:], points[:], me.kzgVk())
+}
+
+// VerifyBatch verifies proofs[i] of vks[i] for publics[i] with a single KZG
+// batch check. When it fails the batch is bisected to find the bad proofs,
+// whose sorted indexes are returned with the error.
+func VerifyBatch(vks []*Vk, proofs []*Proof, publics [][]fr.Element) ([]int, error) {
+	if len(vks) != len(proofs) || len(vks) != len(publics) {
+		return nil, fmt.Errorf("batch size mismatch: %d vks, %d proofs, %d publics", len(vks), len(proofs), len(publics))
+	}
+	if len(vks) == 0 {
+		return nil, nil
+	}
+	digests := make([][2]bls12381.G1Affine, len(vks))
+	openings := make([][2]kzg.OpeningProof, len(vks))
+	points := make([][2]fr.Element, len(vks))
+	errs := make([]error, len(vks))
+	// nil entries fail without being prepared
+	var kvk *kzg.VerifyingKey
+	for i := range vks {
+		if vks[i] == nil || proofs[i] == nil {
+			errs[i] = errors.New("nil key or proof")
+			continue
+		}
+		if v := vks[i].kzgVk(); kvk == nil {
+			kvk = &v
+		} else if v != *kvk {
+			return nil, errors.New("batch of keys of different SRS")
+		}
+	}
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i := range vks {
+		if errs[i] != nil {
+			continue
+		}
+		g.Go(func() error {
+			digests[i], openings[i], points[i], errs[i] = vks[i].prepare(proofs[i], publics[i])
+			return nil
+		})
+	}
+	g.Wait()
+	var failed, pending []int
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, i)
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	var bisect func(idx []int)
+	bisect = func(idx []int) {
+		if len(idx) == 0 {
+			return
+		}
+		ds := make([]bls12381.G1Affine, 0, 2*len(idx))
+		ops := make([]kzg.OpeningProof, 0, 2*len(idx))
+		ps := make([]fr.Element, 0, 2*len(idx))
+		for _, i := range idx {
+			ds = append(ds, digests[i][:]...)
+			ops = append(ops, openings[i][:]...)
+			ps = append(ps, points[i][:]...)
+		}
+		if kzg.BatchVerifyMultiPoints(ds, ops, ps, *kvk) == nil {
+			return
+		}
+		if len(idx) == 1 {
+			failed = append(failed, idx[0])
+			return
+		}
+		bisect(idx[:len(idx)/2])
+		bisect(idx[len(idx)/2:])
+	}
+	bisect(pending)
+	if len(failed) == 0 {
+		return nil, nil
+	}
+	slices.Sort(failed)
+	return failed, fmt.Errorf("%d of %d proofs failed verification", len(failed), len(vks))
+}
+
+func (me *Vk) prepare(proof *Proof, publics []fr.Element) (digests [2]bls12381.G1Affine, proofs [2]kzg.OpeningProof, points [2]fr.Element, err error) {
+	if len(publics) != int(me.NP) {
+		err = fmt.Errorf("number of public inputs is %d not %d", len(publics), me.NP)
+		return
+	}
+	if len(me.QC) != len(me.CI) {
+		err = errors.New("invalid number of commitments")
+		return
+	}
+	if len(proof.BSB) != len(me.QC) || len(proof.CQC) != len(me.QC) {
+		err = fmt.Errorf("number of commitments is %d not %d", len(proof.BSB), len(me.QC))
+		return
+	}
+	for _, v := range append([]bls12381.G1Affine{proof.CW1, proof.CW2, proof.CW3, proof.CPZ, proof.CH1, proof.CH2, proof.CH3, proof.HBP, proof.HZO}, proof.BSB...) {
 		if !v.IsInSubGroup() {
-			return errors.New("G1 not in sub group")
+			err = errors.New("G1 not in sub group")
+			return
 		}
 	}
-	gamma := HashSum(append([]fr.Element{CID_GAMMA, HashG1(me.S1), HashG1(me.S2), HashG1(me.S3), HashG1(me.QL), HashG1(me.QR), HashG1(me.QM), HashG1(me.QO), HashG1(me.QK), HashG1(me.QC), HashG1(proof.CW1), HashG1(proof.CW2), HashG1(proof.CW3)}, publics[:]...)...)
+	// every commitment i is hashed in order: QC_i into γ, BSB_i into α and
+	// HashCompress(PREFIX_BSB, HashG1(BSB_i)) into the public input at NP+CI_i
+	gins := []fr.Element{CID_GAMMA, HashG1(me.S1), HashG1(me.S2), HashG1(me.S3), HashG1(me.QL), HashG1(me.QR), HashG1(me.QM), HashG1(me.QO), HashG1(me.QK)}
+	for _, v := range me.QC {
+		gins = append(gins, HashG1(v))
+	}
+	gins = append(gins, fr.NewElement(uint64(me.NP)), HashG1(proof.CW1), HashG1(proof.CW2), HashG1(proof.CW3))
+	gamma := HashSum(append(gins, publics...)...)
 	beta := HashSum(CID_BETA, gamma)
-	alpha := HashSum(CID_ALPHA, beta, HashG1(proof.BSB), HashG1(proof.CPZ))
+	ains := []fr.Element{CID_ALPHA, beta}
+	for _, v := range proof.BSB {
+		ains = append(ains, HashG1(v))
+	}
+	alpha := HashSum(append(ains, HashG1(proof.CPZ))...)
 	zeta := HashSum(CID_ZETA, alpha, HashG1(proof.CH1), HashG1(proof.CH2), HashG1(proof.CH3))
 	one := fr.One()
 	generator, err := fr.Generator(1 << me.SZ)
 	if err != nil {
-		return err
+		return
 	}
 	var pi, lin, tmp, s1, s2, cz, rl, zetana2zh, zetana2sqzh, zh, sizeinv, l0, alpha2l0, zetas, foldeval fr.Element
 	zh.Exp(zeta, big.NewInt(int64(1<<me.SZ)))
@@ -101,19 +250,22 @@ func (me *Vk) Verify(proof *Proof, publics [4]fr.Element) error {
 	sizeinv.SetUint64(1 << me.SZ).Inverse(&sizeinv)                   // 1/n
 	l0.Sub(&zeta, &one).Inverse(&l0).Mul(&l0, &zh).Mul(&l0, &sizeinv) // 1/n * (ζ^n-1)/(ζ-1)
 	alpha2l0.Mul(&l0, &alpha).Mul(&alpha2l0, &alpha)                  // α²/n * (ζ^n-1)/(ζ-1)
-	hashedcmt := HashCompress(PREFIX_BSB, HashG1(proof.BSB))
-	tmp.Exp(generator, big.NewInt(NUM_PUBLIC+int64(me.CI)))
-	pi.Mul(&zh, &tmp).Div(&pi, tmp.Sub(&zeta, &tmp)).Mul(&pi, &sizeinv).Mul(&pi, &hashedcmt)
-	ws := [4]fr.Element{one, one, one, one}
-	for i := 1; i < len(ws); i++ {
-		ws[i].Mul(&ws[i-1], &generator)
-	}
+	for i, v := range proof.BSB {
+		var li fr.Element
+		hashedcmt := HashCompress(PREFIX_BSB, HashG1(v))
+		tmp.Exp(generator, big.NewInt(int64(me.NP)+int64(me.CI[i])))
+		li.Mul(&zh, &tmp).Div(&li, tmp.Sub(&zeta, &tmp)).Mul(&li, &sizeinv).Mul(&li, &hashedcmt)
+		pi.Add(&pi, &li)
+	}
+	w := one
 	for i := 0; i < len(publics); i++ {
-		pi.Add(&pi, tmp.Sub(&zeta, &ws[i]).Inverse(&tmp).Mul(&tmp, &zh).Mul(&tmp, &sizeinv).Mul(&tmp, &ws[i]).Mul(&tmp, &publics[i]))
+		pi.Add(&pi, tmp.Sub(&zeta, &w).Inverse(&tmp).Mul(&tmp, &zh).Mul(&tmp, &sizeinv).Mul(&tmp, &w).Mul(&tmp, &publics[i]))
+		w.Mul(&w, &generator)
 	}
 	lin.Mul(&beta, &proof.CS1).Add(&lin, &gamma).Add(&lin, &proof.CVL).Mul(&lin, tmp.Mul(&proof.CS2, &beta).Add(&tmp, &gamma).Add(&tmp, &proof.CVR)).Mul(&lin, tmp.Add(&proof.CVO, &gamma)).Mul(&lin, &alpha).Mul(&lin, &proof.CZO).Sub(&lin, &alpha2l0).Add(&lin, &pi).Neg(&lin) // -[PI(ζ) - α²*L₁(ζ) + α(l(ζ)+β*s1(ζ)+γ)(r(ζ)+β*s2(ζ)+γ)(o(ζ)+γ)*z(ωζ)]
 	if !lin.Equal(&proof.COL) {
-		return errors.New("algebraic relation does not hold")
+		err = errors.New("algebraic relation does not hold")
+		return
 	}
 	s1.Mul(&beta, &proof.CS1).Add(&s1, &proof.CVL).Add(&s1, &gamma).Mul(&s1, tmp.Mul(&beta, &proof.CS2).Add(&tmp, &proof.CVR).Add(&tmp, &gamma)).Mul(&s1, &beta).Mul(&s1, &alpha).Mul(&s1, &proof.CZO)                                                                                                           // α*(l(ζ)+β*s1(β)+γ)*(r(ζ)+β*s2(β)+γ)*β*Z(μζ)
 	s2.Mul(&beta, &zeta).Add(&s2, &gamma).Add(&s2, &proof.CVL).Mul(&s2, tmp.Mul(&beta, &COSET_SHIFT).Mul(&tmp, &zeta).Add(&tmp, &gamma).Add(&tmp, &proof.CVR)).Mul(&s2, tmp.Mul(&beta, &COSET_SHIFT).Mul(&tmp, &COSET_SHIFT).Mul(&tmp, &zeta).Add(&tmp, &proof.CVO).Add(&tmp, &gamma)).Mul(&s2, &alpha).Neg(&s2) // -α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
@@ -126,115 +278,147 @@ func (me *Vk) Verify(proof *Proof, publics [4]fr.Element) error {
 	zh.Neg(&zh)
 	zetas.Mul(&zeta, &generator)
 	var lpd bls12381.G1Affine
-	points := []bls12381.G1Affine{proof.BSB, me.QL, me.QR, me.QM, me.QO, me.QK, me.S3, proof.CPZ, proof.CH1, proof.CH2, proof.CH3}
-	scalars := []fr.Element{proof.CQC, proof.CVL, proof.CVR, rl, proof.CVO, one, s1, cz, zh, zetana2zh, zetana2sqzh}
-	if _, err := lpd.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
-		return err
+	lpdpoints := append(slices.Clone(proof.BSB), me.QL, me.QR, me.QM, me.QO, me.QK, me.S3, proof.CPZ, proof.CH1, proof.CH2, proof.CH3)
+	lpdscalars := append(slices.Clone(proof.CQC), proof.CVL, proof.CVR, rl, proof.CVO, one, s1, cz, zh, zetana2zh, zetana2sqzh)
+	if _, err = lpd.MultiExp(lpdpoints, lpdscalars, ecc.MultiExpConfig{}); err != nil {
+		return
 	}
 
 	var folddigest bls12381.G1Affine
-	g := HashSum(CID_GAMMA, zeta, HashG1(lpd), HashG1(proof.CW1), HashG1(proof.CW2), HashG1(proof.CW3), HashG1(me.S1), HashG1(me.S2), HashG1(me.QC), proof.COL, proof.CVL, proof.CVR, proof.CVO, proof.CS1, proof.CS2, proof.CQC, proof.CZO)
-	gs := [7]fr.Element{one, one, one, one, one, one, one}
+	folddigests := append([]bls12381.G1Affine{lpd, proof.CW1, proof.CW2, proof.CW3, me.S1, me.S2}, me.QC...)
+	foldevals := append([]fr.Element{proof.COL, proof.CVL, proof.CVR, proof.CVO, proof.CS1, proof.CS2}, proof.CQC...)
+	fins := []fr.Element{CID_GAMMA, zeta}
+	for _, v := range folddigests {
+		fins = append(fins, HashG1(v))
+	}
+	g := HashSum(append(append(fins, foldevals...), proof.CZO)...)
+	gs := make([]fr.Element, len(foldevals))
+	gs[0] = one
 	for i := 1; i < len(gs); i++ {
 		gs[i].Mul(&gs[i-1], &g)
 	}
-	for i, v := range []fr.Element{proof.COL, proof.CVL, proof.CVR, proof.CVO, proof.CS1, proof.CS2, proof.CQC} {
+	for i, v := range foldevals {
 		foldeval.Add(&foldeval, tmp.Mul(&v, &gs[i]))
 	}
-	_, err = folddigest.MultiExp([]bls12381.G1Affine{lpd, proof.CW1, proof.CW2, proof.CW3, me.S1, me.S2, me.QC}, gs[:], ecc.MultiExpConfig{})
+	_, err = folddigest.MultiExp(folddigests, gs, ecc.MultiExpConfig{})
 	if err != nil {
-		return err
+		return
 	}
-	return kzg.BatchVerifyMultiPoints(
-		[]bls12381.G1Affine{folddigest, proof.CPZ},
-		[]kzg.OpeningProof{{H: proof.HBP, ClaimedValue: foldeval}, {H: proof.HZO, ClaimedValue: proof.CZO}},
-		[]fr.Element{zeta, zetas},
-		SRS_VK,
-	)
+	digests = [2]bls12381.G1Affine{folddigest, proof.CPZ}
+	proofs = [2]kzg.OpeningProof{{H: proof.HBP, ClaimedValue: foldeval}, {H: proof.HZO, ClaimedValue: proof.CZO}}
+	points = [2]fr.Element{zeta, zetas}
+	return
 }
 
 func (me *Vk) Address() fr.Element {
-	return HashCompress(HashSum(HashG1(me.S1), HashG1(me.S2), HashG1(me.S3), HashG1(me.QL), HashG1(me.QR), HashG1(me.QM), HashG1(me.QO), HashG1(me.QK), HashG1(me.QC)), HashCompress(fr.NewElement(uint64(me.CI)), fr.NewElement(uint64(me.SZ))))
+	points := []fr.Element{HashG1(me.S1), HashG1(me.S2), HashG1(me.S3), HashG1(me.QL), HashG1(me.QR), HashG1(me.QM), HashG1(me.QO), HashG1(me.QK)}
+	var params []fr.Element
+	for i := range me.QC {
+		points = append(points, HashG1(me.QC[i]))
+		params = append(params, fr.NewElement(uint64(me.CI[i])))
+	}
+	return HashCompress(HashSum(points...), HashSum(append(params, fr.NewElement(uint64(me.SZ)), fr.NewElement(uint64(me.NP)))...))
+}
+
+// Equal reports whether me and other are the same key.
+func (me *Vk) Equal(other *Vk) bool {
+	return me.S1 == other.S1 && me.S2 == other.S2 && me.S3 == other.S3 && me.QL == other.QL && me.QR == other.QR && me.QM == other.QM && me.QO == other.QO && me.QK == other.QK &&
+		slices.Equal(me.QC, other.QC) && slices.Equal(me.CI, other.CI) && me.SZ == other.SZ && me.NP == other.NP
 }
 
 func (me *Vk) WriteTo(w io.Writer) (int64, error) {
-	enc := bls12381.NewEncoder(w)
-	if err := enc.Encode(&me.S1); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.S2); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.S3); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.QL); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.QR); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.QM); err != nil {
-		return enc.BytesWritten(), err
-	}
-	if err := enc.Encode(&me.QO); err != nil {
-		return enc.BytesWritten(), err
+	return writeContainer(w, KIND_VK, me.writeRawTo)
+}
+
+func (me *Vk) ReadFrom(r io.Reader) (int64, error) {
+	return readContainer(r, KIND_VK, me.readRawFrom)
+}
+
+func (me *Vk) writeRawTo(w io.Writer) (int64, error) {
+	if me.testSRS != nil {
+		return 0, errors.New("key of a test SRS")
 	}
-	if err := enc.Encode(&me.QK); err != nil {
-		return enc.BytesWritten(), err
+	if len(me.QC) != len(me.CI) {
+		return 0, errors.New("invalid number of commitments")
 	}
-	if err := enc.Encode(&me.QC); err != nil {
-		return enc.BytesWritten(), err
+	enc := bls12381.NewEncoder(w)
+	for _, p := range []*bls12381.G1Affine{&me.S1, &me.S2, &me.S3, &me.QL, &me.QR, &me.QM, &me.QO, &me.QK} {
+		if err := enc.Encode(p); err != nil {
+			return enc.BytesWritten(), err
+		}
 	}
-	buf := [4]byte{}
-	binary.BigEndian.PutUint32(buf[:], me.CI)
-	if n, err := w.Write(buf[:]); err != nil {
+	buf := []byte{me.SZ}
+	buf = binary.BigEndian.AppendUint32(buf, me.NP)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(me.QC)))
+	n, err := w.Write(buf)
+	if err != nil {
 		return int64(n) + enc.BytesWritten(), err
 	}
-	if n, err := w.Write([]byte{me.SZ}); err != nil {
-		return int64(n) + 4 + enc.BytesWritten(), err
+	for i := range me.QC {
+		if err := enc.Encode(&me.QC[i]); err != nil {
+			return int64(n) + enc.BytesWritten(), err
+		}
+		m, err := w.Write(binary.BigEndian.AppendUint32(nil, me.CI[i]))
+		n += m
+		if err != nil {
+			return int64(n) + enc.BytesWritten(), err
+		}
 	}
-	return 5 + enc.BytesWritten(), nil
+	return int64(n) + enc.BytesWritten(), nil
 }
 
-func (me *Vk) ReadFrom(r io.Reader) (int64, error) {
+// readRawFrom reads the layout of version: before 3 a key has exactly one
+// commitment stored as QC after QK followed by CI and SZ, and before 2 no NP.
+func (me *Vk) readRawFrom(r io.Reader, version uint16) (int64, error) {
 	dec := bls12381.NewDecoder(r)
-	if err := dec.Decode(&me.S1); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.S2); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.S3); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.QL); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.QR); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.QM); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.QO); err != nil {
-		return dec.BytesRead(), err
-	}
-	if err := dec.Decode(&me.QK); err != nil {
-		return dec.BytesRead(), err
+	for _, p := range []*bls12381.G1Affine{&me.S1, &me.S2, &me.S3, &me.QL, &me.QR, &me.QM, &me.QO, &me.QK} {
+		if err := dec.Decode(p); err != nil {
+			return dec.BytesRead(), err
+		}
 	}
-	if err := dec.Decode(&me.QC); err != nil {
-		return dec.BytesRead(), err
+	if version < 3 {
+		me.QC = make([]bls12381.G1Affine, 1)
+		if err := dec.Decode(&me.QC[0]); err != nil {
+			return dec.BytesRead(), err
+		}
+		buf := [9]byte{}
+		size := 5
+		if version >= 2 {
+			size = 9
+		}
+		if n, err := io.ReadFull(r, buf[:size]); err != nil {
+			return int64(n) + dec.BytesRead(), err
+		}
+		me.CI = []uint32{binary.BigEndian.Uint32(buf[:])}
+		me.SZ = buf[4]
+		me.NP = NUM_PUBLIC
+		if version >= 2 {
+			me.NP = binary.BigEndian.Uint32(buf[5:])
+		}
+		return int64(size) + dec.BytesRead(), nil
 	}
-	buf := [4]byte{}
-	if n, err := io.ReadFull(r, buf[:]); err != nil {
+	buf := [9]byte{}
+	n, err := io.ReadFull(r, buf[:])
+	if err != nil {
 		return int64(n) + dec.BytesRead(), err
 	}
-	me.CI = binary.BigEndian.Uint32(buf[:])
-	sz := [1]byte{}
-	if n, err := io.ReadFull(r, sz[:]); err != nil {
-		return int64(n) + 4 + dec.BytesRead(), err
+	me.SZ = buf[0]
+	me.NP = binary.BigEndian.Uint32(buf[1:])
+	nc := binary.BigEndian.Uint32(buf[5:])
+	// grown one by one, a forged count fails on EOF instead of allocating
+	me.QC, me.CI = nil, nil
+	for range nc {
+		var qc bls12381.G1Affine
+		if err := dec.Decode(&qc); err != nil {
+			return int64(n) + dec.BytesRead(), err
+		}
+		m, err := io.ReadFull(r, buf[:4])
+		n += m
+		if err != nil {
+			return int64(n) + dec.BytesRead(), err
+		}
+		me.QC = append(me.QC, qc)
+		me.CI = append(me.CI, binary.BigEndian.Uint32(buf[:4]))
 	}
-	me.SZ = sz[0]
-	return dec.BytesRead() + 5, nil
+	return int64(n) + dec.BytesRead(), nil
 }
@@ -0,0 +1,279 @@
+package eonark_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/eon-protocol/eonark"
+)
+
+type publicsCircuit struct {
+	Publics []frontend.Variable `gnark:",public"`
+	Sum     frontend.Variable
+}
+
+func (me *publicsCircuit) Define(api frontend.API) error {
+	var sum frontend.Variable = 0
+	for _, v := range me.Publics {
+		sum = api.Add(sum, v)
+	}
+	api.AssertIsEqual(sum, me.Sum)
+	_, err := api.(frontend.Committer).Commit(me.Sum)
+	return err
+}
+
+func TestVkPublics(t *testing.T) {
+	s := testSRS(t, 10)
+	for _, n := range []int{1, 7} {
+		var pk eonark.Pk
+		pk.SetTestSRS(s)
+		if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, n)}); err != nil {
+			t.Fatal(err)
+		}
+		vk := pk.Vk()
+		if vk.NP != uint32(n) {
+			t.Fatalf("got %d public inputs, expected %d", vk.NP, n)
+		}
+		assignment := publicsCircuit{Publics: make([]frontend.Variable, n)}
+		for i := range assignment.Publics {
+			assignment.Publics[i] = i + 1
+		}
+		assignment.Sum = n * (n + 1) / 2
+		publics, _, proof, err := pk.Prove(&assignment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(publics) != n {
+			t.Fatalf("got %d public inputs, expected %d", len(publics), n)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+		if vk.Verify(proof, publics[1:]) == nil || vk.Verify(proof, append(publics, publics[0])) == nil {
+			t.Fatal("proof accepted for a wrong number of public inputs")
+		}
+
+		// keys of a test SRS are not serialized, their points are
+		vk.SetTestSRS(nil)
+		var buf bytes.Buffer
+		if _, err := vk.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var read eonark.Vk
+		if _, err := read.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !read.Equal(&vk) {
+			t.Fatal("vk changed by WriteTo and ReadFrom")
+		}
+		data, err := json.Marshal(&vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &read); err != nil || !read.Equal(&vk) {
+			t.Fatalf("vk changed by JSON: %v", err)
+		}
+
+		// the count is bound to the address of the key
+		other := vk
+		other.NP++
+		if other.Address() == vk.Address() {
+			t.Fatal("keys of different arities have the same address")
+		}
+
+		// keys written before the count was stored have NUM_PUBLIC
+		var legacy bytes.Buffer
+		enc := bls12381.NewEncoder(&legacy)
+		for _, p := range []*bls12381.G1Affine{&vk.S1, &vk.S2, &vk.S3, &vk.QL, &vk.QR, &vk.QM, &vk.QO, &vk.QK, &vk.QC[0]} {
+			if err := enc.Encode(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		legacy.Write(binary.BigEndian.AppendUint32(nil, vk.CI[0]))
+		legacy.WriteByte(vk.SZ)
+		if _, err := read.ReadFrom(&legacy); err != nil {
+			t.Fatal(err)
+		}
+		if read.NP != eonark.NUM_PUBLIC || !slices.Equal(read.QC, vk.QC) || !slices.Equal(read.CI, vk.CI) {
+			t.Fatalf("legacy vk read with %d public inputs", read.NP)
+		}
+		var fromJSON eonark.Vk
+		if err := json.Unmarshal(bytes.Replace(data, []byte(`,"NP":`), []byte(`,"_":`), 1), &fromJSON); err != nil || fromJSON.NP != eonark.NUM_PUBLIC {
+			t.Fatalf("legacy JSON vk read with %d public inputs: %v", fromJSON.NP, err)
+		}
+	}
+}
+
+type commitsCircuit struct {
+	X, Y    frontend.Variable `gnark:",public"`
+	commits int
+}
+
+func (me *commitsCircuit) Define(api frontend.API) error {
+	api.AssertIsDifferent(me.X, me.Y)
+	for i := 0; i < me.commits; i++ {
+		c, err := api.(frontend.Committer).Commit(api.Add(me.X, i), me.Y)
+		if err != nil {
+			return err
+		}
+		api.AssertIsDifferent(c, 0)
+	}
+	return nil
+}
+
+func TestVkCommitments(t *testing.T) {
+	s := testSRS(t, 10)
+	for _, n := range []int{0, 2} {
+		var pk eonark.Pk
+		pk.SetTestSRS(s)
+		if err := pk.Compile(&commitsCircuit{commits: n}); err != nil {
+			t.Fatal(err)
+		}
+		vk := pk.Vk()
+		if len(vk.QC) != n || len(vk.CI) != n {
+			t.Fatalf("got %d commitments, expected %d", len(vk.QC), n)
+		}
+		publics, _, proof, err := pk.Prove(&commitsCircuit{X: 3, Y: 4, commits: n})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proof.BSB) != n || len(proof.CQC) != n {
+			t.Fatalf("got %d commitments in the proof, expected %d", len(proof.BSB), n)
+		}
+		if err := vk.Verify(proof, publics); err != nil {
+			t.Fatal(err)
+		}
+		var gp eonark.Proof
+		if err := gp.FromGnarkProof(proof.ToGnarkPRoof()); err != nil || !gp.Equal(proof) {
+			t.Fatalf("proof changed by the gnark conversion: %v", err)
+		}
+		if n > 1 {
+			swapped := *proof
+			swapped.BSB = []bls12381.G1Affine{proof.BSB[1], proof.BSB[0]}
+			if vk.Verify(&swapped, publics) == nil {
+				t.Fatal("proof accepted with its commitments swapped")
+			}
+		}
+		short := *proof
+		short.BSB, short.CQC = append(short.BSB, proof.CPZ), append(short.CQC, proof.CZO)
+		if vk.Verify(&short, publics) == nil {
+			t.Fatal("proof accepted with an extra commitment")
+		}
+
+		// keys of a test SRS are not serialized, their points are
+		vk.SetTestSRS(nil)
+		var buf bytes.Buffer
+		if _, err := vk.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := proof.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var readVk eonark.Vk
+		var readProof eonark.Proof
+		if _, err := readVk.ReadFrom(&buf); err != nil || !readVk.Equal(&vk) {
+			t.Fatalf("vk changed by WriteTo and ReadFrom: %v", err)
+		}
+		if _, err := readProof.ReadFrom(&buf); err != nil || !readProof.Equal(proof) {
+			t.Fatalf("proof changed by WriteTo and ReadFrom: %v", err)
+		}
+		data, err := json.Marshal(&vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &readVk); err != nil || !readVk.Equal(&vk) {
+			t.Fatalf("vk changed by JSON: %v", err)
+		}
+		if data, err = json.Marshal(proof); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &readProof); err != nil || !readProof.Equal(proof) {
+			t.Fatalf("proof changed by JSON: %v", err)
+		}
+		if err := vk.ExportSolidity(io.Discard); err == nil || proof.MarshalSolidity() != nil {
+			t.Fatal("solidity export of a key without exactly one commitment")
+		}
+	}
+}
+
+func TestGnarkVerify(t *testing.T) {
+	s := testSRS(t, 10)
+	for _, n := range []int{0, 1, 2} {
+		var pk eonark.Pk
+		pk.SetTestSRS(s)
+		if err := pk.Compile(&commitsCircuit{commits: n}); err != nil {
+			t.Fatal(err)
+		}
+		vk := pk.Vk()
+		assignment := commitsCircuit{X: 3, Y: 4, commits: n}
+		_, _, proof, err := pk.Prove(&assignment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := frontend.NewWitness(&assignment, eonark.FIELD, frontend.PublicOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := plonk.Verify(proof.ToGnarkPRoof(), vk.ToGnarkVerifyingKey(), public, eonark.OPT_VERIFIER); err != nil {
+			t.Fatalf("%d commitments: %v", n, err)
+		}
+		wrong, err := frontend.NewWitness(&commitsCircuit{X: 3, Y: 5}, eonark.FIELD, frontend.PublicOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plonk.Verify(proof.ToGnarkPRoof(), vk.ToGnarkVerifyingKey(), wrong, eonark.OPT_VERIFIER) == nil {
+			t.Fatal("proof accepted for other public inputs")
+		}
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	var pk eonark.Pk
+	pk.SetTestSRS(testSRS(t, 10))
+	if err := pk.Compile(&publicsCircuit{Publics: make([]frontend.Variable, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	vk := pk.Vk()
+	var vks []*eonark.Vk
+	var proofs []*eonark.Proof
+	var publics [][]fr.Element
+	for i := 0; i < 6; i++ {
+		pub, _, proof, err := pk.Prove(&publicsCircuit{Publics: []frontend.Variable{i, 1}, Sum: i + 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		vks, proofs, publics = append(vks, &vk), append(proofs, proof), append(publics, pub)
+	}
+	if failed, err := eonark.VerifyBatch(vks, proofs, publics); err != nil || failed != nil {
+		t.Fatalf("valid batch failed at %v: %v", failed, err)
+	}
+	// one proof failing only the KZG check is found by bisection, one with
+	// the wrong number of public inputs before it
+	publics[4][0].SetUint64(7)
+	publics[1] = publics[1][:1]
+	failed, err := eonark.VerifyBatch(vks, proofs, publics)
+	if err == nil || !slices.Equal(failed, []int{1, 4}) {
+		t.Fatalf("got failed %v: %v", failed, err)
+	}
+	// nil entries fail instead of crashing the batch
+	vks[0], proofs[5] = nil, nil
+	failed, err = eonark.VerifyBatch(vks, proofs, publics)
+	if err == nil || !slices.Equal(failed, []int{0, 1, 4, 5}) {
+		t.Fatalf("got failed %v: %v", failed, err)
+	}
+	if failed, err := eonark.VerifyBatch([]*eonark.Vk{nil}, []*eonark.Proof{nil}, [][]fr.Element{nil}); err == nil || !slices.Equal(failed, []int{0}) {
+		t.Fatalf("got failed %v: %v", failed, err)
+	}
+	if _, err := eonark.VerifyBatch(vks, proofs[1:], publics); err == nil {
+		t.Fatal("batch of mismatched sizes verified")
+	}
+}
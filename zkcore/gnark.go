@@ -0,0 +1,147 @@
+package zkcore
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// The hash.Hash below let gnark's plonk verifier, which writes its transcript
+// as bytes, compute the challenges of the eonark transcript. gnark writes
+// every item with its own Write: a challenge name, a 32-byte field element,
+// or a raw 96-byte G1 point that is hashed with HashG1.
+
+const (
+	gnarkChallenge = iota
+	gnarkFolding
+	gnarkCommitment
+)
+
+type gnarkItem struct {
+	size  int
+	value fr.Element
+}
+
+type gnarkHash struct {
+	mode  int
+	items []gnarkItem
+	err   error
+}
+
+// NewChallengeHash returns the hash of the gamma, beta, alpha and zeta
+// challenges. gnark binds the public inputs before the three wire
+// commitments and not their number, gamma is reordered to the eonark order.
+func NewChallengeHash() hash.Hash {
+	return &gnarkHash{mode: gnarkChallenge}
+}
+
+// NewFoldingHash returns the hash of the challenge folding the batch opening.
+func NewFoldingHash() hash.Hash {
+	return &gnarkHash{mode: gnarkFolding}
+}
+
+// NewCommitmentHash returns the hash of a BSB22 commitment into the public
+// input, HashCompress(PREFIX_BSB, HashG1(commitment)).
+func NewCommitmentHash() hash.Hash {
+	return &gnarkHash{mode: gnarkCommitment}
+}
+
+func (me *gnarkHash) Write(p []byte) (int, error) {
+	if me.err != nil {
+		return 0, me.err
+	}
+	item := gnarkItem{size: len(p)}
+	switch len(p) {
+	case fr.Bytes:
+		if err := item.value.SetBytesCanonical(p); err != nil {
+			me.err = err
+		}
+	case curve.SizeOfG1AffineCompressed, curve.SizeOfG1AffineUncompressed:
+		var point curve.G1Affine
+		if _, err := point.SetBytes(p); err != nil {
+			me.err = err
+		}
+		item.value = HashG1(point)
+	default:
+		switch string(p) {
+		case "gamma":
+			item.value = CID_GAMMA
+		case "beta":
+			item.value = CID_BETA
+		case "alpha":
+			item.value = CID_ALPHA
+		case "zeta":
+			item.value = CID_ZETA
+		default:
+			me.err = fmt.Errorf("unexpected transcript item of %d bytes", len(p))
+		}
+	}
+	if me.err != nil {
+		return 0, me.err
+	}
+	me.items = append(me.items, item)
+	return len(p), nil
+}
+
+func (me *gnarkHash) Sum(b []byte) []byte {
+	res, err := me.sum()
+	if err != nil {
+		// hash.Hash cannot fail, an invalid transcript gives a zero challenge
+		res = fr.Element{}
+	}
+	bytes := res.Bytes()
+	return append(b, bytes[:]...)
+}
+
+func (me *gnarkHash) sum() (fr.Element, error) {
+	if me.err != nil {
+		return fr.Element{}, me.err
+	}
+	switch me.mode {
+	case gnarkCommitment:
+		if len(me.items) != 1 || me.items[0].size == fr.Bytes {
+			return fr.Element{}, errors.New("commitment hash expects one point")
+		}
+		return HashCompress(PREFIX_BSB, me.items[0].value), nil
+	case gnarkChallenge:
+		if len(me.items) > 0 && me.items[0].size != fr.Bytes && me.items[0].value == CID_GAMMA {
+			// CID_GAMMA, S1..QK, QC..., NP, CW1..3, publics...
+			var points, publics []fr.Element
+			for _, v := range me.items[1:] {
+				if v.size == fr.Bytes {
+					publics = append(publics, v.value)
+				} else {
+					points = append(points, v.value)
+				}
+			}
+			if len(points) < 3 {
+				return fr.Element{}, errors.New("gamma is not bound to the wire commitments")
+			}
+			wires := points[len(points)-3:]
+			vals := append([]fr.Element{CID_GAMMA}, points[:len(points)-3]...)
+			vals = append(vals, fr.NewElement(uint64(len(publics))))
+			vals = append(vals, wires...)
+			return HashSum(append(vals, publics...)...), nil
+		}
+	}
+	vals := make([]fr.Element, len(me.items))
+	for i, v := range me.items {
+		vals[i] = v.value
+	}
+	return HashSum(vals...), nil
+}
+
+func (me *gnarkHash) Reset() {
+	me.items, me.err = nil, nil
+}
+
+func (me *gnarkHash) Size() int {
+	return fr.Bytes
+}
+
+func (me *gnarkHash) BlockSize() int {
+	return fr.Bytes
+}
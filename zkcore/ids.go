@@ -0,0 +1,53 @@
+package zkcore
+
+import (
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+var CID_GAMMA = func() (val fr.Element) {
+	val.SetString("12136437972164249638515815863518169381248623050802518443499856540155713785793")
+	return
+}()
+var CID_BETA = func() (val fr.Element) {
+	val.SetString("18573803297957083279407999548582433273399322018814582391185078724486099338357")
+	return
+}()
+var CID_ALPHA = func() (val fr.Element) {
+	val.SetString("49747578351961873600101888628702675272467029400415710410441263855875020310598")
+	return
+}()
+var CID_ZETA = func() (val fr.Element) {
+	val.SetString("39057712567180736910604556313519348712189848041390074835666431785905701131882")
+	return
+}()
+var PREFIX_BSB = func() (val fr.Element) {
+	val.SetString("25462560578134928990029001067183171577145376707459712415971543462128145703592")
+	return
+}()
+
+// DecomposeG1 splits the coordinates of val as X = xq*r + xm, Y = yq*r + ym
+// and returns [[xq, xm], [yq, ym]].
+func DecomposeG1(val bls12381.G1Affine) [2][2]fr.Element {
+	var ixq, ixm, iyq, iym big.Int
+	var exq, exm, eyq, eym fr.Element
+	val.X.BigInt(&ixq)
+	val.Y.BigInt(&iyq)
+	ixq.DivMod(&ixq, fr.Modulus(), &ixm)
+	iyq.DivMod(&iyq, fr.Modulus(), &iym)
+	exq.SetBigInt(&ixq)
+	exm.SetBigInt(&ixm)
+	eyq.SetBigInt(&iyq)
+	eym.SetBigInt(&iym)
+	return [2][2]fr.Element{{exq, exm}, {eyq, eym}}
+}
+
+// HashG1 is Compress(Compress(xq, xm), Compress(yq, ym)).
+func HashG1(val bls12381.G1Affine) fr.Element {
+	decompose := DecomposeG1(val)
+	x := HashCompress(decompose[0][0], decompose[0][1])
+	y := HashCompress(decompose[1][0], decompose[1][1])
+	return HashCompress(x, y)
+}
@@ -0,0 +1,66 @@
+// Package zkcore holds the Poseidon2 hash and the Fiat-Shamir transcript
+// shared by the CPU prover, the GPU prover, the native verifier and the
+// in-circuit gadget of circuits/hasher. Any change here changes every proof,
+// the outputs are pinned by testvectors.
+package zkcore
+
+import (
+	"log"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/poseidon2"
+)
+
+const HASH_T = 2
+const HASH_RF = 8
+const HASH_RP = 56
+const HASH_SEED = "EON_POSEIDON2_HASH_SEED"
+
+// Hasher is the Poseidon2 hash over values of T: fr.Element natively,
+// frontend.Variable in circuits.
+type Hasher[T any] interface {
+	// Compress returns Permutation(x, y)[1] + y.
+	Compress(x, y T) T
+	// Sum folds vals with Compress, starting from zero.
+	Sum(vals ...T) T
+}
+
+// Poseidon2 is the native Hasher.
+type Poseidon2 struct{}
+
+var POSEIDON2 Hasher[fr.Element] = Poseidon2{}
+
+var Parameters = sync.OnceValue(func() *poseidon2.Parameters {
+	return poseidon2.NewParametersWithSeed(HASH_T, HASH_RF, HASH_RP, HASH_SEED)
+})
+
+var Permutation = sync.OnceValue(func() *poseidon2.Permutation {
+	return poseidon2.NewPermutationWithSeed(HASH_T, HASH_RF, HASH_RP, HASH_SEED)
+})
+
+func (Poseidon2) Compress(x, y fr.Element) fr.Element {
+	return HashCompress(x, y)
+}
+
+func (Poseidon2) Sum(vals ...fr.Element) fr.Element {
+	return HashSum(vals...)
+}
+
+func HashCompress(x, y fr.Element) fr.Element {
+	vars := [2]fr.Element{x, y}
+	if err := Permutation().Permutation(vars[:]); err != nil {
+		log.Fatalln(err)
+	}
+	var ret fr.Element
+	ret.Add(&vars[1], &y)
+	return ret
+}
+
+func HashSum(val ...fr.Element) fr.Element {
+	var ret fr.Element
+	for _, v := range val {
+		ret = HashCompress(ret, v)
+	}
+	return ret
+}
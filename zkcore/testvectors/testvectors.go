@@ -0,0 +1,83 @@
+// Package testvectors holds known answers of the zkcore hash and transcript.
+// Every implementation, native, in-circuit or on-chain, is tested against
+// them, so that none of them can drift without failing its tests.
+package testvectors
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+//go:embed vectors.json
+var vectors []byte
+
+// Vectors are decimal strings, field elements are canonical. The transcript
+// challenges are CID_GAMMA, CID_BETA, CID_ALPHA and CID_ZETA in that order.
+type Vectors struct {
+	Constants   map[string]string `json:"constants"`
+	Permutation []struct {
+		In  [2]string `json:"in"`
+		Out [2]string `json:"out"`
+	} `json:"permutation"`
+	Compress []struct {
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		Out string `json:"out"`
+	} `json:"compress"`
+	Sum []struct {
+		In  []string `json:"in"`
+		Out string   `json:"out"`
+	} `json:"sum"`
+	HashG1 []struct {
+		Scalar string `json:"scalar"`
+		X      string `json:"x"`
+		Y      string `json:"y"`
+		Out    string `json:"out"`
+	} `json:"hash_g1"`
+	Transcript []struct {
+		Bindings   [4][]string `json:"bindings"`
+		Challenges [4]string   `json:"challenges"`
+	} `json:"transcript"`
+}
+
+func Load() (ret Vectors, err error) {
+	err = json.Unmarshal(vectors, &ret)
+	return
+}
+
+func Fr(s string) (ret fr.Element) {
+	if _, err := ret.SetString(s); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func Frs(s []string) []fr.Element {
+	ret := make([]fr.Element, len(s))
+	for i, v := range s {
+		ret[i] = Fr(v)
+	}
+	return ret
+}
+
+func G1(x, y string) (ret bls12381.G1Affine) {
+	if _, err := ret.X.SetString(x); err != nil {
+		panic(err)
+	}
+	if _, err := ret.Y.SetString(y); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func Int(s string) *big.Int {
+	ret, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid integer " + s)
+	}
+	return ret
+}
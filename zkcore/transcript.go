@@ -0,0 +1,137 @@
+package zkcore
+
+import (
+	"errors"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	plonkbls12381 "github.com/consensys/gnark/backend/plonk/bls12-381"
+)
+
+var (
+	errChallengeNotFound            = errors.New("challenge not recorded in the transcript")
+	errChallengeAlreadyComputed     = errors.New("challenge already computed, cannot be binded to other values")
+	errPreviousChallengeNotComputed = errors.New("the previous challenge is needed and has not been computed")
+)
+
+// Transcript handles the creation of challenges for Fiat Shamir.
+type Transcript struct {
+	hasher     Hasher[fr.Element]
+	challenges map[fr.Element]challenge
+	previous   *challenge
+}
+
+type challenge struct {
+	position   int            // position of the challenge in the Transcript. order matters.
+	bindings   [][]fr.Element // bindings stores the variables a challenge is binded to.
+	value      fr.Element     // value stores the computed challenge
+	isComputed bool
+}
+
+// NewTranscript returns a new transcript.
+// h is the hash function that is used to compute the challenges.
+// challenges are the name of the challenges. The order of the challenges IDs matters.
+func NewTranscript(h Hasher[fr.Element], challengesID ...fr.Element) *Transcript {
+	challenges := make(map[fr.Element]challenge)
+	for i := range challengesID {
+		challenges[challengesID[i]] = challenge{position: i}
+	}
+	t := &Transcript{
+		hasher:     h,
+		challenges: challenges,
+	}
+	return t
+}
+
+// Bind binds the challenge to value. A challenge can be binded to an
+// arbitrary number of values, but the order in which the binded values
+// are added is important. Once a challenge is computed, it cannot be
+// binded to other values.
+func (t *Transcript) Bind(challengeID fr.Element, bValue ...fr.Element) error {
+
+	currentChallenge, ok := t.challenges[challengeID]
+	if !ok {
+		return errChallengeNotFound
+	}
+
+	if currentChallenge.isComputed {
+		return errChallengeAlreadyComputed
+	}
+
+	bCopy := make([]fr.Element, len(bValue))
+	copy(bCopy, bValue)
+	currentChallenge.bindings = append(currentChallenge.bindings, bCopy)
+	t.challenges[challengeID] = currentChallenge
+
+	return nil
+
+}
+
+// ComputeChallenge computes the challenge corresponding to the given name.
+// The challenge is:
+// * H(name || previous_challenge || binded_values...) if the challenge is not the first one
+// * H(name || binded_values... ) if it is the first challenge
+func (t *Transcript) ComputeChallenge(challengeID fr.Element) (fr.Element, error) {
+	challenge, ok := t.challenges[challengeID]
+	if !ok {
+		return fr.Element{}, errChallengeNotFound
+	}
+
+	// if the challenge was already computed we return it
+	if challenge.isComputed {
+		return challenge.value, nil
+	}
+
+	// reset before populating the internal state
+	resfrom := []fr.Element{}
+
+	resfrom = append(resfrom, challengeID)
+
+	// write the previous challenge if it's not the first challenge
+	if challenge.position != 0 {
+		if t.previous == nil || (t.previous.position != challenge.position-1) {
+			return fr.Element{}, errPreviousChallengeNotComputed
+		}
+		resfrom = append(resfrom, t.previous.value)
+	}
+
+	// write the binded values in the order they were added
+	for _, b := range challenge.bindings {
+		resfrom = append(resfrom, b...)
+	}
+
+	// compute the hash of the accumulated values
+	res := t.hasher.Sum(resfrom...)
+
+	challenge.value = res
+	challenge.isComputed = true
+
+	t.challenges[challengeID] = challenge
+	t.previous = &challenge
+
+	return res, nil
+
+}
+
+// BindVerifyingKey binds the permutation and selector commitments of vk and
+// its number of public inputs to challenge, in the order the verifier hashes
+// them.
+func BindVerifyingKey(fs *Transcript, challenge fr.Element, vk *plonkbls12381.VerifyingKey) error {
+	points := []curve.G1Affine{vk.S[0], vk.S[1], vk.S[2], vk.Ql, vk.Qr, vk.Qm, vk.Qo, vk.Qk}
+	for _, p := range append(points, vk.Qcp...) {
+		if err := fs.Bind(challenge, HashG1(p)); err != nil {
+			return err
+		}
+	}
+	return fs.Bind(challenge, fr.NewElement(vk.NbPublicVariables))
+}
+
+// DeriveRandomness binds points to challenge and computes it.
+func DeriveRandomness(fs *Transcript, challenge fr.Element, points ...*curve.G1Affine) (fr.Element, error) {
+	for _, p := range points {
+		if err := fs.Bind(challenge, HashG1(*p)); err != nil {
+			return fr.Element{}, err
+		}
+	}
+	return fs.ComputeChallenge(challenge)
+}
@@ -0,0 +1,101 @@
+package zkcore_test
+
+import (
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+
+	"github.com/eon-protocol/eonark/zkcore"
+	"github.com/eon-protocol/eonark/zkcore/testvectors"
+)
+
+func TestVectors(t *testing.T) {
+	vectors, err := testvectors.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]fr.Element{
+		"cid_gamma":  zkcore.CID_GAMMA,
+		"cid_beta":   zkcore.CID_BETA,
+		"cid_alpha":  zkcore.CID_ALPHA,
+		"cid_zeta":   zkcore.CID_ZETA,
+		"prefix_bsb": zkcore.PREFIX_BSB,
+	} {
+		if expected := testvectors.Fr(vectors.Constants[name]); v != expected {
+			t.Errorf("%s: got %s, expected %s", name, v.String(), expected.String())
+		}
+	}
+	for i, v := range vectors.Permutation {
+		vars := [2]fr.Element{testvectors.Fr(v.In[0]), testvectors.Fr(v.In[1])}
+		if err := zkcore.Permutation().Permutation(vars[:]); err != nil {
+			t.Fatal(err)
+		}
+		if vars != [2]fr.Element{testvectors.Fr(v.Out[0]), testvectors.Fr(v.Out[1])} {
+			t.Errorf("permutation %d differs", i)
+		}
+	}
+	for i, v := range vectors.Compress {
+		x, y := testvectors.Fr(v.X), testvectors.Fr(v.Y)
+		if zkcore.POSEIDON2.Compress(x, y) != testvectors.Fr(v.Out) {
+			t.Errorf("compress %d differs", i)
+		}
+	}
+	for i, v := range vectors.Sum {
+		if zkcore.POSEIDON2.Sum(testvectors.Frs(v.In)...) != testvectors.Fr(v.Out) {
+			t.Errorf("sum %d differs", i)
+		}
+	}
+	_, _, g, _ := bls12381.Generators()
+	for i, v := range vectors.HashG1 {
+		var p bls12381.G1Affine
+		p.ScalarMultiplication(&g, testvectors.Int(v.Scalar))
+		if p != testvectors.G1(v.X, v.Y) {
+			t.Fatalf("hash_g1 %d is not scalar·G", i)
+		}
+		if zkcore.HashG1(p) != testvectors.Fr(v.Out) {
+			t.Errorf("hash_g1 %d differs", i)
+		}
+	}
+	ids := []fr.Element{zkcore.CID_GAMMA, zkcore.CID_BETA, zkcore.CID_ALPHA, zkcore.CID_ZETA}
+	for i, v := range vectors.Transcript {
+		fs := zkcore.NewTranscript(zkcore.POSEIDON2, ids...)
+		for j, id := range ids {
+			if err := fs.Bind(id, testvectors.Frs(v.Bindings[j])...); err != nil {
+				t.Fatal(err)
+			}
+			challenge, err := fs.ComputeChallenge(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if challenge != testvectors.Fr(v.Challenges[j]) {
+				t.Errorf("transcript %d challenge %d differs", i, j)
+			}
+		}
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	fs := zkcore.NewTranscript(zkcore.POSEIDON2, zkcore.CID_GAMMA, zkcore.CID_BETA)
+	if _, err := fs.ComputeChallenge(zkcore.CID_BETA); err == nil {
+		t.Error("beta computed before gamma")
+	}
+	if err := fs.Bind(zkcore.CID_ZETA, zkcore.PREFIX_BSB); err == nil {
+		t.Error("bound an unknown challenge")
+	}
+	gamma, err := fs.ComputeChallenge(zkcore.CID_GAMMA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Bind(zkcore.CID_GAMMA, gamma); err == nil {
+		t.Error("bound a computed challenge")
+	}
+	// without bindings a challenge is the hash of its id and the previous one
+	beta, err := fs.ComputeChallenge(zkcore.CID_BETA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beta != zkcore.HashSum(zkcore.CID_BETA, gamma) {
+		t.Error("beta is not HashSum(CID_BETA, gamma)")
+	}
+}